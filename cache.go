@@ -0,0 +1,177 @@
+package owl
+
+import (
+	"container/list"
+	"context"
+	"reflect"
+	"sync"
+)
+
+// TreeCache caches the built resolver trees, without any options applied. It
+// must be safe for concurrent use.
+type TreeCache interface {
+	// Load returns the cached tree of the given key.
+	Load(key TreeCacheKey) (*Resolver, bool)
+
+	// Store caches the tree of the given key.
+	Store(key TreeCacheKey, tree *Resolver)
+
+	// Invalidate removes the cached trees of the given type, no matter which
+	// overlays they were built with.
+	Invalidate(typ reflect.Type)
+
+	// Purge removes all the cached trees.
+	Purge()
+
+	// Types lists the types of all the cached trees.
+	Types() []reflect.Type
+
+	// Stats returns the statistics of the cache.
+	Stats() TreeCacheStats
+}
+
+// TreeCacheKey is the key of a cached tree. Since the tag overlays take part
+// in building the trees, the trees of the same type built with different
+// overlays are cached separately.
+type TreeCacheKey struct {
+	Type    reflect.Type
+	Overlay string // fingerprint of the overlays, empty if no overlays applied
+	Dynamic bool   // whether the untagged interface fields are kept, see WithDynamicTypesEnabled
+}
+
+// TreeCacheStats is the statistics of a TreeCache.
+type TreeCacheStats struct {
+	Hits      uint64 // number of loads that hit the cache
+	Misses    uint64 // number of loads that missed the cache
+	Evictions uint64 // number of trees evicted due to the size limit
+	Size      int    // number of trees in the cache
+}
+
+var defaultTreeCache TreeCache = NewLRUTreeCache(0)
+
+// UseTreeCache sets the global tree cache, which is used by New when no cache
+// is specified by WithTreeCache. Like UseTag, it should be called before any
+// callings to New, typically in an init function.
+func UseTreeCache(cache TreeCache) {
+	defaultTreeCache = cache
+}
+
+// DefaultTreeCache returns the global tree cache. By default, it is an
+// unbounded LRUTreeCache.
+func DefaultTreeCache() TreeCache {
+	return defaultTreeCache
+}
+
+// treeCache returns the tree cache bound to the context, or the global one.
+func treeCache(ctx context.Context) TreeCache {
+	if cache, ok := ctx.Value(ckTreeCache).(TreeCache); ok && cache != nil {
+		return cache
+	}
+	return defaultTreeCache
+}
+
+// LRUTreeCache is a TreeCache which evicts the least recently used trees when
+// the number of cached trees exceeds its size limit.
+type LRUTreeCache struct {
+	mu      sync.Mutex
+	maxSize int
+	ll      *list.List
+	items   map[TreeCacheKey]*list.Element
+	stats   TreeCacheStats
+}
+
+type lruEntry struct {
+	key  TreeCacheKey
+	tree *Resolver
+}
+
+// NewLRUTreeCache creates an LRUTreeCache which holds at most maxSize trees.
+// A non-positive maxSize means unbounded.
+func NewLRUTreeCache(maxSize int) *LRUTreeCache {
+	return &LRUTreeCache{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[TreeCacheKey]*list.Element),
+	}
+}
+
+func (c *LRUTreeCache) Load(key TreeCacheKey) (*Resolver, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.stats.Hits++
+		c.ll.MoveToFront(elem)
+		return elem.Value.(*lruEntry).tree, true
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+func (c *LRUTreeCache) Store(key TreeCacheKey, tree *Resolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lruEntry).tree = tree
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key, tree})
+	for c.maxSize > 0 && c.ll.Len() > c.maxSize {
+		c.remove(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *LRUTreeCache) Invalidate(typ reflect.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.items {
+		if key.Type == typ {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *LRUTreeCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[TreeCacheKey]*list.Element)
+}
+
+// Types lists the types of all the cached trees, from the most recently used
+// to the least recently used.
+func (c *LRUTreeCache) Types() []reflect.Type {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	types := make([]reflect.Type, 0, c.ll.Len())
+	seen := make(map[reflect.Type]bool)
+	for elem := c.ll.Front(); elem != nil; elem = elem.Next() {
+		typ := elem.Value.(*lruEntry).key.Type
+		if !seen[typ] {
+			seen[typ] = true
+			types = append(types, typ)
+		}
+	}
+	return types
+}
+
+func (c *LRUTreeCache) Stats() TreeCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.ll.Len()
+	return stats
+}
+
+func (c *LRUTreeCache) remove(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,126 @@
+package owl_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUTreeCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := owl.NewLRUTreeCache(2)
+
+	typeA := reflect.TypeOf(Pagination{})
+	typeB := reflect.TypeOf(User{})
+	typeC := reflect.TypeOf(UserSignUpForm{})
+	treeA, treeB, treeC := &owl.Resolver{Type: typeA}, &owl.Resolver{Type: typeB}, &owl.Resolver{Type: typeC}
+	keyA, keyB, keyC := owl.TreeCacheKey{Type: typeA}, owl.TreeCacheKey{Type: typeB}, owl.TreeCacheKey{Type: typeC}
+
+	_, ok := cache.Load(keyA)
+	assert.False(ok)
+
+	cache.Store(keyA, treeA)
+	cache.Store(keyB, treeB)
+	tree, ok := cache.Load(keyA) // A becomes the most recently used
+	assert.True(ok)
+	assert.Same(treeA, tree)
+	assert.Equal([]reflect.Type{typeA, typeB}, cache.Types())
+
+	cache.Store(keyC, treeC) // evicts B
+	_, ok = cache.Load(keyB)
+	assert.False(ok)
+	assert.Equal([]reflect.Type{typeC, typeA}, cache.Types())
+	assert.Equal(owl.TreeCacheStats{Hits: 1, Misses: 2, Evictions: 1, Size: 2}, cache.Stats())
+
+	cache.Invalidate(typeC)
+	cache.Invalidate(typeB) // no-op
+	assert.Equal([]reflect.Type{typeA}, cache.Types())
+
+	// Invalidate all the trees of the same type.
+	cache.Store(owl.TreeCacheKey{Type: typeA, Overlay: "1@1"}, treeA)
+	assert.Equal([]reflect.Type{typeA}, cache.Types())
+	assert.Equal(2, cache.Stats().Size)
+	cache.Invalidate(typeA)
+	assert.Equal(0, cache.Stats().Size)
+
+	cache.Store(keyA, treeA)
+	cache.Purge()
+	assert.Empty(cache.Types())
+	assert.Equal(0, cache.Stats().Size)
+}
+
+func TestLRUTreeCache_Unbounded(t *testing.T) {
+	cache := owl.NewLRUTreeCache(0)
+	for i := 0; i < 100; i++ {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: "F", Type: reflect.TypeOf(i), Tag: reflect.StructTag(`owl:"form=f"`)},
+			{Name: "Pad", Type: reflect.ArrayOf(i, reflect.TypeOf(byte(0)))},
+		})
+		_, err := owl.New(typ, owl.WithTreeCache(cache))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, owl.TreeCacheStats{Misses: 100, Size: 100}, cache.Stats())
+}
+
+func TestWithTreeCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := owl.NewLRUTreeCache(10)
+
+	type Request struct {
+		Drawing
+		Pagination
+	}
+
+	r1, err := owl.New(Request{}, owl.WithTreeCache(cache), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+	assert.Equal([]reflect.Type{reflect.TypeOf(Request{})}, cache.Types())
+	assert.NotContains(owl.DefaultTreeCache().Types(), reflect.TypeOf(Request{}))
+
+	r2, err := owl.New(Request{}, owl.WithTreeCache(cache), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+	assert.NotSame(r1, r2)
+	assert.Equal(owl.TreeCacheStats{Hits: 1, Misses: 1, Size: 1}, cache.Stats())
+
+	// The trees keeping the untagged interface fields are cached separately.
+	_, err = owl.New(Request{}, owl.WithTreeCache(cache))
+	assert.NoError(err)
+	assert.Equal(owl.TreeCacheStats{Hits: 1, Misses: 2, Size: 2}, cache.Stats())
+
+	// The dynamic subtrees are cached in the same cache.
+	ns, _ := createNsForTracking()
+	assert.NoError(r1.Scan(&Request{Drawing: Drawing{Shape: Square{}}}, owl.WithNamespace(ns)))
+	assert.Equal([]reflect.Type{reflect.TypeOf(Square{}), reflect.TypeOf(Request{})}, cache.Types())
+}
+
+func TestUseTreeCache(t *testing.T) {
+	defaultCache := owl.DefaultTreeCache()
+	defer owl.UseTreeCache(defaultCache)
+
+	cache := owl.NewLRUTreeCache(1)
+	owl.UseTreeCache(cache)
+	assert.Same(t, cache, owl.DefaultTreeCache())
+
+	_, err := owl.New(Pagination{})
+	assert.NoError(t, err)
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(Pagination{})}, cache.Types())
+}
+
+func TestLRUTreeCache_Concurrency(t *testing.T) {
+	cache := owl.NewLRUTreeCache(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := owl.New(UserSignUpForm{}, owl.WithTreeCache(cache))
+			assert.NoError(t, err)
+			cache.Stats()
+			cache.Types()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(10), cache.Stats().Hits+cache.Stats().Misses)
+}
@@ -5,4 +5,18 @@ type contextKey int
 const (
 	ckNamespace contextKey = iota
 	ckResolveNestedDirectives
+	ckLazyAllocation
+	ckDynamicTypes
+	ckConcreteTypeFunc
+	ckNamingStrategy
+	ckTreeCache
+	ckExcludedFields
+	ckOverlays
+	ckFailFast
+	ckMaxErrors
+	ckOnlyPaths
+	ckExcludedPaths
+	ckTransaction
+	ckObserver
+	ckPanicRecovery
 )
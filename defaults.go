@@ -0,0 +1,91 @@
+package owl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterTypeDirectives registers type-level default directives globally, see
+// OverlaySet.RegisterTypeDirectives.
+func RegisterTypeDirectives(typeValue any, tag string) error {
+	return defaultOverlays.RegisterTypeDirectives(typeValue, tag)
+}
+
+// RegisterKindDirectives registers kind-level default directives globally, see
+// OverlaySet.RegisterKindDirectives.
+func RegisterKindDirectives(kind reflect.Kind, tag string) error {
+	return defaultOverlays.RegisterKindDirectives(kind, tag)
+}
+
+// RegisterTypeDirectives registers the directives (in the form of a struct
+// tag) which will be added to every field of the given type automatically,
+// while building the resolver trees. The type can be given as a value of the
+// type or a reflect.Type. A field of a pointer type also matches the
+// directives registered to its element type. Ex:
+//
+//	overlays.RegisterTypeDirectives(time.Time{}, "format=rfc3339")
+//	overlays.RegisterTypeDirectives(uuid.UUID{}, "uuid")
+//
+// The default directives are appended to the directives in the struct tag.
+// The tag can override a default directive by defining a directive of the same
+// name, or suppress it by "-name". Ex:
+//
+//	type Event struct {
+//	    CreatedAt time.Time `owl:"form=created_at;format=unix"` // override
+//	    UpdatedAt time.Time `owl:"form=updated_at;-format"`     // suppress
+//	}
+//
+// Registering to the same type again replaces the former directives.
+func (s *OverlaySet) RegisterTypeDirectives(typeValue any, tag string) error {
+	typ, ok := typeValue.(reflect.Type)
+	if !ok {
+		typ = reflect.TypeOf(typeValue)
+	}
+	if typ == nil {
+		return fmt.Errorf("%w: nil type", ErrUnsupportedType)
+	}
+
+	directives, err := ParseTag(tag)
+	if err != nil {
+		return fmt.Errorf("invalid directives for type %v: %w", typ, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types[typ] = directives
+	s.version++
+	return nil
+}
+
+// RegisterKindDirectives works like RegisterTypeDirectives, but the directives
+// will be added to every field of the given kind, e.g. reflect.String. The
+// type-level directives take precedence over the kind-level ones, i.e. the
+// kind-level directives won't be added to a field which has type-level
+// directives.
+func (s *OverlaySet) RegisterKindDirectives(kind reflect.Kind, tag string) error {
+	directives, err := ParseTag(tag)
+	if err != nil {
+		return fmt.Errorf("invalid directives for kind %v: %w", kind, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind] = directives
+	s.version++
+	return nil
+}
+
+// defaultDirectives returns the default directives of the given field type.
+// NOTE: s.mu must be held.
+func (s *OverlaySet) defaultDirectives(typ reflect.Type) []*Directive {
+	if directives, ok := s.types[typ]; ok {
+		return directives
+	}
+	if typ.Kind() == reflect.Ptr {
+		if directives, ok := s.types[typ.Elem()]; ok {
+			return directives
+		}
+		typ = typ.Elem()
+	}
+	return s.kinds[typ.Kind()]
+}
@@ -0,0 +1,105 @@
+package owl_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type EventForm struct {
+	Name      string     `owl:"form=name"`
+	CreatedAt time.Time  `owl:"form=created_at"`
+	UpdatedAt *time.Time `owl:"form=updated_at;format=unix"`
+	DeletedAt time.Time  `owl:"form=deleted_at;-format"`
+	ExpiredAt time.Time
+	Count     int `owl:"form=count"`
+}
+
+func TestOverlaySet_RegisterTypeDirectives(t *testing.T) {
+	assert := assert.New(t)
+	overlays := owl.NewOverlaySet()
+	assert.NoError(overlays.RegisterTypeDirectives(time.Time{}, "format=rfc3339"))
+	assert.NoError(overlays.RegisterKindDirectives(reflect.String, "trim"))
+	assert.NoError(overlays.RegisterKindDirectives(reflect.Struct, "ignored"))
+
+	resolver, err := owl.New(EventForm{}, owl.WithOverlays(overlays))
+	assert.NoError(err)
+
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "name"),
+		owl.NewDirective("trim"),
+	}, resolver.Lookup("Name").Directives)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "created_at"),
+		owl.NewDirective("format", "rfc3339"),
+	}, resolver.Lookup("CreatedAt").Directives, "type-level directives take precedence over kind-level ones")
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "updated_at"),
+		owl.NewDirective("format", "unix"),
+	}, resolver.Lookup("UpdatedAt").Directives, "pointer matches the element type, overridden by tag")
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "deleted_at"),
+	}, resolver.Lookup("DeletedAt").Directives, "suppressed by tag")
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("format", "rfc3339"),
+	}, resolver.Lookup("ExpiredAt").Directives, "untagged fields also get the defaults")
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "count"),
+	}, resolver.Lookup("Count").Directives)
+
+	// Without the defaults.
+	resolver, err = owl.New(EventForm{})
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "created_at")}, resolver.Lookup("CreatedAt").Directives)
+	assert.Nil(resolver.Lookup("ExpiredAt"))
+}
+
+func TestOverlaySet_RegisterTypeDirectives_WithOverlays(t *testing.T) {
+	assert := assert.New(t)
+	overlays := owl.NewOverlaySet()
+	assert.NoError(overlays.RegisterTypeDirectives(reflect.TypeOf(""), "trim"))
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyConfig{}, "Endpoint", "-trim", owl.OverlayMerge))
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyAuth{}, "Secret", "env=SECRET", owl.OverlayReplace))
+
+	resolver, err := owl.New(AppConfig{}, owl.WithOverlays(overlays))
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "endpoint"),
+		owl.NewDirective("default", "localhost"),
+	}, resolver.Lookup("Config.Endpoint").Directives)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("env", "SECRET"),
+		owl.NewDirective("trim"),
+	}, resolver.Lookup("Config.Auth.Secret").Directives)
+	assert.Equal([]*owl.Directive{owl.NewDirective("trim")}, resolver.Lookup("Config.Auth.Token").Directives)
+}
+
+func TestOverlaySet_RegisterTypeDirectives_Invalidates(t *testing.T) {
+	assert := assert.New(t)
+	cache := owl.NewLRUTreeCache(0)
+	overlays := owl.NewOverlaySet()
+
+	_, err := owl.New(EventForm{}, owl.WithOverlays(overlays), owl.WithTreeCache(cache))
+	assert.NoError(err)
+
+	assert.NoError(overlays.RegisterTypeDirectives(time.Time{}, "format=rfc3339"))
+	resolver, err := owl.New(EventForm{}, owl.WithOverlays(overlays), owl.WithTreeCache(cache))
+	assert.NoError(err)
+	assert.NotNil(resolver.Lookup("ExpiredAt"))
+	assert.Equal(2, cache.Stats().Size)
+}
+
+func TestOverlaySet_RegisterTypeDirectives_Invalid(t *testing.T) {
+	overlays := owl.NewOverlaySet()
+	assert.ErrorIs(t, overlays.RegisterTypeDirectives(nil, "trim"), owl.ErrUnsupportedType)
+	assert.ErrorIs(t, overlays.RegisterOverlay(AppConfig{}, "Name", "-in-valid", owl.OverlayMerge), owl.ErrInvalidDirectiveName)
+
+	type BadForm struct {
+		Name string `owl:"form=name;-in-valid"`
+	}
+	_, err := owl.New(BadForm{})
+	assert.ErrorIs(t, err, owl.ErrInvalidDirectiveName)
+}
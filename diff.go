@@ -0,0 +1,260 @@
+package owl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ChangeKind is the kind of a change between two resolver trees.
+type ChangeKind int
+
+const (
+	FieldAdded          ChangeKind = iota // a field (with its subtree) was added
+	FieldRemoved                          // a field (with its subtree) was removed
+	TypeChanged                           // the type of a field was changed
+	DirectiveAdded                        // a directive was added to a field
+	DirectiveRemoved                      // a directive was removed from a field
+	DirectiveChanged                      // the argv or the phase of a directive was changed
+	DirectivesReordered                   // the execution order of the directives was changed
+)
+
+var changeKindNames = map[ChangeKind]string{
+	FieldAdded:          "field added",
+	FieldRemoved:        "field removed",
+	TypeChanged:         "type changed",
+	DirectiveAdded:      "directive added",
+	DirectiveRemoved:    "directive removed",
+	DirectiveChanged:    "directive changed",
+	DirectivesReordered: "directives reordered",
+}
+
+func (k ChangeKind) String() string {
+	if name, ok := changeKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Compatibility classifies the changes between two resolver trees.
+type Compatibility int
+
+const (
+	Identical Compatibility = iota // no changes
+	Additive                       // only new fields without directives were added
+	Breaking                       // the tag contract was changed, see Change.Compatibility
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Identical:
+		return "identical"
+	case Additive:
+		return "additive"
+	case Breaking:
+		return "breaking"
+	}
+	return fmt.Sprintf("Compatibility(%d)", int(c))
+}
+
+// Change is a change between two resolver trees, located by the path of the
+// field. For the directive changes, Old and New are the directives in string
+// form. For TypeChanged, they are the types. For DirectivesReordered, they are
+// the directive lists. For FieldAdded, New is the directives carried by the
+// added field and its subtree, in the tag form, e.g. "form=email;required".
+type Change struct {
+	Kind      ChangeKind
+	Path      string
+	Directive string // name of the directive, only for the directive changes
+	Struct    bool   // whether the directive is a struct-level one, see BlankField
+	Old       string
+	New       string
+}
+
+// Compatibility returns Additive for FieldAdded without directives, and
+// Breaking for the others. An added field carrying directives is breaking,
+// since it's a new input to resolve, which can be mandatory, e.g. "required",
+// while the directives can't be told apart by their names.
+func (c *Change) Compatibility() Compatibility {
+	if c.Kind == FieldAdded && c.New == "" {
+		return Additive
+	}
+	return Breaking
+}
+
+func (c *Change) String() string {
+	path := fmt.Sprintf("%q", c.Path)
+	if c.Struct {
+		path += " (struct)"
+	}
+	switch c.Kind {
+	case FieldRemoved:
+		return fmt.Sprintf("%s: %s", c.Kind, path)
+	case FieldAdded:
+		if c.New == "" {
+			return fmt.Sprintf("%s: %s", c.Kind, path)
+		}
+		return fmt.Sprintf("%s: %s %s", c.Kind, path, c.New)
+	case DirectiveAdded:
+		return fmt.Sprintf("%s: %s %s", c.Kind, path, c.New)
+	case DirectiveRemoved:
+		return fmt.Sprintf("%s: %s %s", c.Kind, path, c.Old)
+	}
+	return fmt.Sprintf("%s: %s %s -> %s", c.Kind, path, c.Old, c.New)
+}
+
+// TreeDiff is the result of Diff.
+type TreeDiff struct {
+	Changes []*Change
+}
+
+// Compatibility returns the most severe compatibility of all the changes.
+func (d *TreeDiff) Compatibility() Compatibility {
+	compatibility := Identical
+	for _, change := range d.Changes {
+		if c := change.Compatibility(); c > compatibility {
+			compatibility = c
+		}
+	}
+	return compatibility
+}
+
+// IsBreaking reports whether there are any breaking changes.
+func (d *TreeDiff) IsBreaking() bool {
+	return d.Compatibility() == Breaking
+}
+
+// Diff compares two resolver trees structurally, typically built from two
+// releases of the same struct, and reports the changes of the tag contract,
+// i.e. the added/removed fields, the changed types and the added, removed or
+// changed directives, including the struct-level ones, located by paths. The
+// fields are matched by their names (see WithNamingStrategy). The changes are
+// listed in depth-first order. When a field was added or removed, its subtree
+// won't be reported separately.
+func Diff(a, b *Resolver) *TreeDiff {
+	diff := &TreeDiff{}
+	diff.compare(a, b)
+	return diff
+}
+
+func (d *TreeDiff) add(kind ChangeKind, path, directive, oldValue, newValue string) {
+	d.Changes = append(d.Changes, &Change{
+		Kind:      kind,
+		Path:      path,
+		Directive: directive,
+		Old:       oldValue,
+		New:       newValue,
+	})
+}
+
+func (d *TreeDiff) compare(a, b *Resolver) {
+	path := b.PathString()
+
+	// The root types always differ between releases, so they're not compared.
+	if !a.IsRoot() && !b.IsRoot() && isTypeChanged(a, b) {
+		d.add(TypeChanged, path, "", a.Type.String(), b.Type.String())
+	}
+	d.compareDirectives(path, a.Directives, b.Directives, false)
+	d.compareDirectives(path, a.PreDirectives, b.PreDirectives, true)
+	d.compareDirectives(path, a.PostDirectives, b.PostDirectives, true)
+
+	for _, childA := range a.Children {
+		if childB := findChildByName(b, childA.Name()); childB != nil {
+			d.compare(childA, childB)
+		} else {
+			d.add(FieldRemoved, childA.PathString(), "", "", "")
+		}
+	}
+	for _, childB := range b.Children {
+		if findChildByName(a, childB.Name()) == nil {
+			d.add(FieldAdded, childB.PathString(), "", "", strings.Join(subtreeDirectives(childB), ";"))
+		}
+	}
+}
+
+// compareDirectives compares the directives of a field. The struct-level ones
+// (see BlankField) are compared separately, marked by isStruct.
+func (d *TreeDiff) compareDirectives(path string, a, b []*Directive, isStruct bool) {
+	start := len(d.Changes)
+	defer func() {
+		for _, change := range d.Changes[start:] {
+			change.Struct = isStruct
+		}
+	}()
+
+	var commonA, commonB []string
+	for _, da := range a {
+		db := findDirective(b, da.Name)
+		if db == nil {
+			d.add(DirectiveRemoved, path, da.Name, da.String(), "")
+			continue
+		}
+		commonA = append(commonA, da.Name)
+		if isDirectiveChanged(da, db) {
+			d.add(DirectiveChanged, path, da.Name, da.String(), db.String())
+		}
+	}
+	for _, db := range b {
+		if findDirective(a, db.Name) == nil {
+			d.add(DirectiveAdded, path, db.Name, "", db.String())
+		} else {
+			commonB = append(commonB, db.Name)
+		}
+	}
+	if !reflect.DeepEqual(commonA, commonB) {
+		d.add(DirectivesReordered, path, "", fmt.Sprint(commonA), fmt.Sprint(commonB))
+	}
+}
+
+// subtreeDirectives lists the directives of the field and its subtree, in
+// depth-first order, including the struct-level ones.
+func subtreeDirectives(r *Resolver) []string {
+	var directives []string
+	r.Iterate(func(x *Resolver) error {
+		for _, list := range [][]*Directive{x.Directives, x.PreDirectives, x.PostDirectives} {
+			for _, d := range list {
+				directives = append(directives, d.String())
+			}
+		}
+		return nil
+	})
+	return directives
+}
+
+// isTypeChanged reports whether the type of the field was changed. The nested
+// structs (having children) are compared by their shapes, i.e. struct or
+// pointer to struct, since their names usually differ between releases. While
+// the others are compared by their type names.
+func isTypeChanged(a, b *Resolver) bool {
+	if !a.IsLeaf() && !b.IsLeaf() {
+		return (a.Type.Kind() == reflect.Ptr) != (b.Type.Kind() == reflect.Ptr)
+	}
+	return a.Type.String() != b.Type.String()
+}
+
+// isDirectiveChanged reports whether the argv or the execution phase (see
+// PostOrder) of the directive was changed.
+func isDirectiveChanged(a, b *Directive) bool {
+	if a.PostOrder != b.PostOrder {
+		return true
+	}
+	return !reflect.DeepEqual(a.Argv, b.Argv) && (len(a.Argv) > 0 || len(b.Argv) > 0)
+}
+
+func findChildByName(r *Resolver, name string) *Resolver {
+	for _, child := range r.Children {
+		if child.Name() == name {
+			return child
+		}
+	}
+	return nil
+}
+
+func findDirective(directives []*Directive, name string) *Directive {
+	for _, d := range directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
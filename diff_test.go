@@ -0,0 +1,166 @@
+package owl_test
+
+import (
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type AddressV1 struct {
+	City string `owl:"form=city"`
+}
+
+type SignUpV1 struct {
+	Name     string `owl:"form=name;required"`
+	Age      int    `owl:"form=age;default=18"`
+	Role     string `owl:"form=role;header=x-role"`
+	Nickname string `owl:"form=nickname"`
+	Address  *AddressV1
+}
+
+type AddressV2 struct {
+	City    string `owl:"form=city"`
+	ZipCode string `owl:"form=zip"`
+}
+
+type SignUpV2 struct {
+	Name    string `owl:"form=username;required"`
+	Age     string `owl:"form=age"`
+	Role    string `owl:"header=x-role;form=role;required"`
+	Address *AddressV2
+	Email   string `owl:"form=email"`
+}
+
+func TestDiff(t *testing.T) {
+	v1, err := owl.New(SignUpV1{})
+	assert.NoError(t, err)
+	v2, err := owl.New(SignUpV2{})
+	assert.NoError(t, err)
+
+	diff := owl.Diff(v1, v2)
+	assert.Equal(t, []*owl.Change{
+		{Kind: owl.DirectiveChanged, Path: "Name", Directive: "form", Old: "form=name", New: "form=username"},
+		{Kind: owl.TypeChanged, Path: "Age", Old: "int", New: "string"},
+		{Kind: owl.DirectiveRemoved, Path: "Age", Directive: "default", Old: "default=18"},
+		{Kind: owl.DirectiveAdded, Path: "Role", Directive: "required", New: "required"},
+		{Kind: owl.DirectivesReordered, Path: "Role", Old: "[form header]", New: "[header form]"},
+		{Kind: owl.FieldRemoved, Path: "Nickname"},
+		{Kind: owl.FieldAdded, Path: "Address.ZipCode", New: "form=zip"},
+		{Kind: owl.FieldAdded, Path: "Email", New: "form=email"},
+	}, diff.Changes)
+	assert.Equal(t, owl.Breaking, diff.Compatibility())
+	assert.True(t, diff.IsBreaking())
+
+	assert.Equal(t, `directive changed: "Name" form=name -> form=username`, diff.Changes[0].String())
+	assert.Equal(t, `type changed: "Age" int -> string`, diff.Changes[1].String())
+	assert.Equal(t, `directive removed: "Age" default=18`, diff.Changes[2].String())
+	assert.Equal(t, `directive added: "Role" required`, diff.Changes[3].String())
+	assert.Equal(t, `field removed: "Nickname"`, diff.Changes[5].String())
+	assert.Equal(t, `field added: "Email" form=email`, diff.Changes[7].String())
+}
+
+func TestDiff_Compatibility(t *testing.T) {
+	v1, err := owl.New(AddressV1{})
+	assert.NoError(t, err)
+	v2, err := owl.New(AddressV2{})
+	assert.NoError(t, err)
+
+	assert.Empty(t, owl.Diff(v1, v1.Copy()).Changes)
+	assert.Equal(t, owl.Identical, owl.Diff(v1, v1.Copy()).Compatibility())
+	assert.Equal(t, owl.Breaking, owl.Diff(v2, v1).Compatibility())
+
+	// Adding a field carrying directives is breaking, e.g. a new required
+	// input, while adding a field without directives is additive.
+	assert.Equal(t, owl.Breaking, owl.Diff(v1, v2).Compatibility())
+	assert.True(t, owl.Diff(v1, v2).IsBreaking())
+	type AddressV3 struct {
+		City  string `owl:"form=city"`
+		Extra any
+	}
+	v3, err := owl.New(AddressV3{}, owl.WithDynamicTypesEnabled(true))
+	assert.NoError(t, err)
+	assert.Equal(t, owl.Additive, owl.Diff(v1, v3).Compatibility())
+	assert.False(t, owl.Diff(v1, v3).IsBreaking())
+
+	type SignUpV3 struct {
+		SignUpV1
+		Contact struct {
+			Phone string `owl:"form=phone;required"`
+		}
+	}
+	signUpV1, err := owl.New(SignUpV1{})
+	assert.NoError(t, err)
+	signUpV3, err := owl.New(SignUpV3{})
+	assert.NoError(t, err)
+	assert.Contains(t, owl.Diff(signUpV1, signUpV3).Changes, &owl.Change{Kind: owl.FieldAdded, Path: "Contact", New: "form=phone;required"})
+
+	assert.Equal(t, "identical", owl.Identical.String())
+	assert.Equal(t, "additive", owl.Additive.String())
+	assert.Equal(t, "breaking", owl.Breaking.String())
+	assert.Equal(t, "field added", owl.FieldAdded.String())
+}
+
+func TestDiff_PointerShape(t *testing.T) {
+	type Request struct {
+		Address AddressV1
+	}
+	v1, err := owl.New(SignUpV1{})
+	assert.NoError(t, err)
+	v2, err := owl.New(Request{})
+	assert.NoError(t, err)
+
+	changes := owl.Diff(v1, v2).Changes
+	assert.Contains(t, changes, &owl.Change{Kind: owl.TypeChanged, Path: "Address", Old: "*owl_test.AddressV1", New: "owl_test.AddressV1"})
+}
+
+func TestDiff_PostOrder(t *testing.T) {
+	type FormV1 struct {
+		Address *AddressV1 `owl:"form=address;validate"`
+	}
+	type FormV2 struct {
+		Address *AddressV1 `owl:"form=address;after:validate"`
+	}
+	v1, err := owl.New(FormV1{})
+	assert.NoError(t, err)
+	v2, err := owl.New(FormV2{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*owl.Change{
+		{Kind: owl.DirectiveChanged, Path: "Address", Directive: "validate", Old: "validate", New: "after:validate"},
+	}, owl.Diff(v1, v2).Changes)
+}
+
+func TestDiff_StructDirectives(t *testing.T) {
+	assert := assert.New(t)
+	type FormV1 struct {
+		_    struct{} `owl:"oneof=A,B"`
+		A    string   `owl:"form=a"`
+		B    string   `owl:"form=b"`
+		Auth struct {
+			Token string   `owl:"form=token"`
+			_     struct{} `owl:"verify"`
+		}
+	}
+	type FormV2 struct {
+		_    struct{} `owl:"oneof=A,C"`
+		A    string   `owl:"form=a"`
+		B    string   `owl:"form=b"`
+		Auth struct {
+			Token string `owl:"form=token"`
+		}
+	}
+	v1, err := owl.New(FormV1{})
+	assert.NoError(err)
+	v2, err := owl.New(FormV2{})
+	assert.NoError(err)
+
+	diff := owl.Diff(v1, v2)
+	assert.Equal([]*owl.Change{
+		{Kind: owl.DirectiveChanged, Path: "", Directive: "oneof", Struct: true, Old: "oneof=A,B", New: "oneof=A,C"},
+		{Kind: owl.DirectiveRemoved, Path: "Auth", Directive: "verify", Struct: true, Old: "verify"},
+	}, diff.Changes)
+	assert.Equal(owl.Breaking, diff.Compatibility())
+	assert.Equal(`directive changed: "" (struct) oneof=A,B -> oneof=A,C`, diff.Changes[0].String())
+	assert.Equal(`directive removed: "Auth" (struct) verify`, diff.Changes[1].String())
+}
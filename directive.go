@@ -12,10 +12,20 @@ var reDirectiveName = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 // Directive defines the profile to locate a `DirectiveExecutor` instance
 // and drives it with essential arguments.
 type Directive struct {
-	Name string   // name of the executor
-	Argv []string // argv
+	Name      string   // name of the executor
+	Argv      []string // argv
+	PostOrder bool     // run after the children of the field, see PostOrderPrefix
 }
 
+// PostOrderPrefix marks a directive in the struct tag to run after the
+// children of the field are resolved (or scanned), i.e. in post-order, while
+// by default the directives run before the children. Ex:
+//
+//	type Order struct {
+//	    Payment *Payment `owl:"form=payment;after:validate"`
+//	}
+const PostOrderPrefix = "after:"
+
 // NewDirective creates a Directive instance.
 func NewDirective(name string, argv ...string) *Directive {
 	return &Directive{
@@ -31,8 +41,11 @@ func NewDirective(name string, argv ...string) *Directive {
 //
 //	"form=page,page_index" -> { Name: "form", Args: ["page", "page_index"] }
 //	"header=x-api-token"   -> { Name: "header", Args: ["x-api-token"] }
+//	"after:validate"       -> { Name: "validate", PostOrder: true }
 func ParseDirective(directive string) (*Directive, error) {
 	directive = strings.TrimSpace(directive)
+	postOrder := strings.HasPrefix(directive, PostOrderPrefix)
+	directive = strings.TrimPrefix(directive, PostOrderPrefix)
 	parts := strings.SplitN(directive, "=", 2)
 	name := parts[0]
 	var argv []string
@@ -47,20 +60,28 @@ func ParseDirective(directive string) (*Directive, error) {
 		return nil, invalidDirectiveName(name)
 	}
 
-	return NewDirective(name, argv...), nil
+	d := NewDirective(name, argv...)
+	d.PostOrder = postOrder
+	return d, nil
 }
 
 // Copy creates a copy of the directive. The copy is a deep copy.
 func (d *Directive) Copy() *Directive {
-	return NewDirective(d.Name, d.Argv...)
+	copied := NewDirective(d.Name, d.Argv...)
+	copied.PostOrder = d.PostOrder
+	return copied
 }
 
 // String returns the string representation of the directive.
 func (d *Directive) String() string {
+	name := d.Name
+	if d.PostOrder {
+		name = PostOrderPrefix + name
+	}
 	if len(d.Argv) == 0 {
-		return d.Name
+		return name
 	}
-	return d.Name + "=" + strings.Join(d.Argv, ",")
+	return name + "=" + strings.Join(d.Argv, ",")
 }
 
 // DirectiveExecutor is the interface that wraps the Execute method.
@@ -77,11 +98,45 @@ func (f DirectiveExecutorFunc) Execute(de *DirectiveRuntime) error {
 	return f(de)
 }
 
+// PostOrderExecutor is a DirectiveExecutor which requests its directives to
+// run in post-order, i.e. after the children of the field are resolved (or
+// scanned). Which is useful to validate or transform a fully resolved
+// sub-object. See also PostOrder and PostOrderPrefix.
+type PostOrderExecutor interface {
+	DirectiveExecutor
+	PostOrder() bool
+}
+
+// PostOrder wraps the executor as a PostOrderExecutor. Ex:
+//
+//	owl.RegisterDirectiveExecutor("validate", owl.PostOrder(validator))
+func PostOrder(exe DirectiveExecutor) DirectiveExecutor {
+	return postOrderExecutor{exe}
+}
+
+type postOrderExecutor struct {
+	DirectiveExecutor
+}
+
+func (postOrderExecutor) PostOrder() bool {
+	return true
+}
+
+// isPostOrder reports whether the directive runs in post-order, requested by
+// either the directive itself or its executor.
+func isPostOrder(d *Directive, exe DirectiveExecutor) bool {
+	if d.PostOrder {
+		return true
+	}
+	poe, ok := exe.(PostOrderExecutor)
+	return ok && poe.PostOrder()
+}
+
 // DirectiveRuntime is the execution runtime/context of a directive. NOTE: the
 // Directive and Resolver are both exported for the convenience but in an unsafe
-// way. The user should not modify them. If you want to modify them, please call
-// Resolver.Iterate to iterate the resolvers and modify them in the callback.
-// And make sure this be done before any callings to Resolver.Resolve.
+// way. The user should not modify them. If you want to modify the directives,
+// please use the mutators of Resolver, e.g. Resolver.AddDirective, before any
+// callings to Resolver.Resolve, ResolveTo or Scan, which freeze the tree.
 type DirectiveRuntime struct {
 	Directive *Directive
 	Resolver  *Resolver
@@ -92,10 +147,13 @@ type DirectiveRuntime struct {
 	// value is stored in Value.Elem().Int(). The same for other types. So if you
 	// want to modify the field value, you should call Value.Elem().Set(value),
 	// e.g. Value.Elem().SetString(value), Value.Elem().SetInt(value), etc.
+	// For the struct-level directives (see BlankField), it is the struct value
+	// itself, also a pointer to the struct while resolving.
 	Value reflect.Value
 
 	// Context is the runtime context of the directive execution. The initial
-	// context can be tweaked by applying options to Resolver.Resolve method.
+	// context can be seeded by Resolver.ResolveContext (or ScanContext), and
+	// tweaked by applying options to Resolver.Resolve method.
 	// Use WithValue option to set a value to the initial context. Each field
 	// resolver will creates a new context by copying this initial context. And
 	// for the directives of the same field resolver, they will use the same
@@ -115,7 +173,19 @@ type DirectiveRuntime struct {
 	// Thus, all the directives during execution can access the value of "color" in Context_0.
 	// For the Name field resolver, it has two directives, dirA and dirB. They will use the same context Context_1.
 	// and if in dirA we set a value of "foo" to "bar", then in dirB we can get the value of "foo" as "bar".
+	// The post-order directives (see PostOrder) of a field continue with the context left by the
+	// pre-order ones.
 	Context context.Context
+
+	valueSet bool // whether the executor reported a value set, see MarkValueSet
+}
+
+// MarkValueSet reports that the executor has set a value to the field. The
+// changes of the field value are detected without it, so it's only needed when
+// the value set equals the value before, e.g. a zero value set to a zero value.
+// See WithLazyAllocation.
+func (rtm *DirectiveRuntime) MarkValueSet() {
+	rtm.valueSet = true
 }
 
 func isValidDirectiveName(name string) bool {
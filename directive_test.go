@@ -43,6 +43,16 @@ func TestParseDirective(t *testing.T) {
 			expected: owl.NewDirective("header", "x-api-token"),
 			err:      nil,
 		},
+		{
+			content:  "after:validate=strict",
+			expected: &owl.Directive{Name: "validate", Argv: []string{"strict"}, PostOrder: true},
+			err:      nil,
+		},
+		{
+			content:  "after:",
+			expected: nil,
+			err:      owl.ErrInvalidDirectiveName,
+		},
 		{
 			content:  "",
 			expected: nil,
@@ -73,4 +83,8 @@ func TestDirective_String(t *testing.T) {
 
 	d = owl.NewDirective("required")
 	assert.Equal(t, "required", d.String())
+
+	d = &owl.Directive{Name: "validate", Argv: []string{"strict"}, PostOrder: true}
+	assert.Equal(t, "after:validate=strict", d.String())
+	assert.Equal(t, d, d.Copy())
 }
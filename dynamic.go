@@ -0,0 +1,138 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ConcreteTypeFunc picks the concrete type to instantiate for a nil interface
+// field during Resolve. The given resolver is the one of the interface field.
+// Returning nil leaves the field as nil. The returned type must implement the
+// interface type of the field, it can be either a struct type or a pointer to
+// a struct type. Ex:
+//
+//	func(r *owl.Resolver, ctx context.Context) reflect.Type {
+//	    if r.Type == reflect.TypeOf((*Shape)(nil)).Elem() {
+//	        return reflect.TypeOf(&Circle{})
+//	    }
+//	    return nil
+//	}
+//
+// Alternatively, a directive executor running on the interface field can also
+// set the value of the field, which will be resolved by its dynamic type then.
+type ConcreteTypeFunc func(r *Resolver, ctx context.Context) reflect.Type
+
+func shouldResolveDynamicType(ctx context.Context, r *Resolver) bool {
+	if r.Type.Kind() != reflect.Interface {
+		return false
+	}
+	if enabled, _ := lookupOption(ctx, r, ckDynamicTypes).(bool); !enabled {
+		return false
+	}
+	return isNestedDirectivesEnabled(ctx, r)
+}
+
+// dynamicSubtree returns a resolver tree built from the dynamic type (a struct
+// type) of the value held by the interface field r. The tree is rooted at the
+// dynamic value, which means the indexes of its nodes are relative to the
+// dynamic value. While the paths are prefixed with the path of r, and the
+// parent of the subtree is r, to locate the nodes in the whole tree.
+func (r *Resolver) dynamicSubtree(typ reflect.Type) (*Resolver, error) {
+	tree, err := buildAndCacheResolverTree(treeCache(r.Context), overlaySet(r.Context), typ, true)
+	if err != nil {
+		return nil, fmt.Errorf("build resolver for dynamic type %v failed: %w", typ, err)
+	}
+
+	subtree := tree.Copy()
+	subtree.Parent = r
+	subtree.Iterate(func(x *Resolver) error {
+		x.Path = append(append([]string{}, r.Path...), x.Path...)
+		x.Context = r.Context
+		return nil
+	})
+	return subtree, nil
+}
+
+// resolveDynamicType resolves the value held by the interface field. Reports
+// whether any directive set a value, see WithLazyAllocation.
+// NOTE: rv must be a pointer to the interface field.
+func (r *Resolver) resolveDynamicType(ctx context.Context, rv reflect.Value) (bool, error) {
+	iface := rv.Elem()
+	if iface.IsNil() {
+		fn, _ := lookupOption(ctx, r, ckConcreteTypeFunc).(ConcreteTypeFunc)
+		if fn == nil {
+			return false, nil
+		}
+		typ := fn(r, ctx)
+		if typ == nil {
+			return false, nil
+		}
+		if !typ.Implements(r.Type) {
+			return false, fmt.Errorf("%w: concrete type %q does not implement %q", ErrTypeMismatch, typ, r.Type)
+		}
+		if typ.Kind() == reflect.Ptr {
+			iface.Set(reflect.New(typ.Elem()))
+		} else {
+			iface.Set(reflect.New(typ).Elem())
+		}
+	}
+
+	// The struct to resolve must be addressable. If the interface holds a
+	// struct value rather than a pointer, resolve a copy and set it back.
+	dynamic := iface.Elem()
+	var target reflect.Value
+	switch {
+	case dynamic.Kind() == reflect.Ptr && dynamic.Type().Elem().Kind() == reflect.Struct:
+		if dynamic.IsNil() {
+			return false, nil
+		}
+		target = dynamic
+	case dynamic.Kind() == reflect.Struct:
+		target = reflect.New(dynamic.Type())
+		target.Elem().Set(dynamic)
+	default:
+		return false, nil // not a struct, nothing to resolve
+	}
+
+	subtree, err := r.dynamicSubtree(target.Type().Elem())
+	if err != nil {
+		return false, err
+	}
+	set, err := subtree.resolve(ctx, target, nil)
+	if err != nil && err != ErrStop {
+		return false, err
+	}
+	if dynamic.Kind() == reflect.Struct {
+		iface.Set(target.Elem())
+	}
+	return set, err
+}
+
+// scanDynamicType scans the value held by the interface field.
+func (r *Resolver) scanDynamicType(ctx context.Context, fv reflect.Value) error {
+	if fv.IsNil() {
+		return nil
+	}
+	dynamic := fv.Elem()
+	if dynamic.Kind() == reflect.Ptr {
+		if dynamic.IsNil() {
+			return nil
+		}
+		dynamic = dynamic.Elem()
+	}
+	if dynamic.Kind() != reflect.Struct {
+		return nil // not a struct, nothing to scan
+	}
+
+	subtree, err := r.dynamicSubtree(dynamic.Type())
+	if err != nil {
+		return &ScanError{
+			fieldError: fieldError{
+				Err:      err,
+				Resolver: r,
+			},
+		}
+	}
+	return subtree.scan(ctx, dynamic, nil)
+}
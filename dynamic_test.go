@@ -0,0 +1,216 @@
+package owl_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type Shape interface {
+	Area() int
+}
+
+type Square struct {
+	Side int `owl:"form=side"`
+}
+
+func (s Square) Area() int { return s.Side * s.Side }
+
+type Rectangle struct {
+	Width  int `owl:"form=width"`
+	Height int `owl:"form=height"`
+}
+
+func (r *Rectangle) Area() int { return r.Width * r.Height }
+
+type Drawing struct {
+	Title   string `owl:"form=title"`
+	Shape   Shape
+	Payload any `owl:"form=payload"`
+}
+
+func TestNew_KeepInterfaceFields(t *testing.T) {
+	resolver, err := owl.New(Drawing{}, owl.WithDynamicTypesEnabled(true))
+	assert.NoError(t, err)
+	assert.NotNil(t, resolver.Lookup("Shape"))
+	assert.True(t, resolver.Lookup("Shape").IsLeaf())
+	assert.NotNil(t, resolver.Lookup("Payload"))
+
+	// The untagged interface fields are skipped if not enabled in New.
+	resolver, err = owl.New(Drawing{})
+	assert.NoError(t, err)
+	assert.Nil(t, resolver.Lookup("Shape"))
+	assert.NotNil(t, resolver.Lookup("Payload"))
+}
+
+func TestNew_UntaggedInterfaceFields_DynamicTypesDisabled(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking("noop")
+
+	type B struct {
+		Err error
+	}
+	type A struct {
+		Name string `owl:"noop"`
+		P    *B
+	}
+	resolver, err := owl.New(A{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+	assert.Nil(resolver.Lookup("P"))
+
+	assert.NoError(resolver.Scan(&A{}))
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Nil(gotValue.Interface().(*A).P)
+}
+
+func TestScan_DynamicTypes(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	resolver, err := owl.New(Drawing{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+
+	drawing := &Drawing{
+		Title:   "art",
+		Shape:   &Rectangle{Width: 2, Height: 3},
+		Payload: Square{Side: 4},
+	}
+
+	// Disabled in Scan.
+	assert.NoError(resolver.Scan(drawing, owl.WithDynamicTypesEnabled(false)))
+	assert.Equal(ExecutedDataList{
+		{owl.NewDirective("form", "title"), "art"},
+		{owl.NewDirective("form", "payload"), drawing.Payload},
+	}, tracker.Executed)
+
+	tracker.Reset()
+	assert.NoError(resolver.Scan(drawing, owl.WithDynamicTypesEnabled(true)))
+	assert.Equal(ExecutedDataList{
+		{owl.NewDirective("form", "title"), "art"},
+		{owl.NewDirective("form", "width"), 2},
+		{owl.NewDirective("form", "height"), 3},
+		{owl.NewDirective("form", "payload"), drawing.Payload},
+		{owl.NewDirective("form", "side"), 4},
+	}, tracker.Executed)
+
+	// Respect WithNestedDirectivesEnabled.
+	tracker.Reset()
+	assert.NoError(resolver.Scan(drawing, owl.WithDynamicTypesEnabled(true), owl.WithNestedDirectivesEnabled(false)))
+	assert.Equal(ExecutedDataList{
+		{owl.NewDirective("form", "title"), "art"},
+		{owl.NewDirective("form", "width"), 2},
+		{owl.NewDirective("form", "height"), 3},
+		{owl.NewDirective("form", "payload"), drawing.Payload},
+	}, tracker.Executed)
+
+	// Nil interface and non-struct values are skipped.
+	tracker.Reset()
+	assert.NoError(resolver.Scan(&Drawing{Payload: 1}, owl.WithDynamicTypesEnabled(true)))
+	assert.Equal(ExecutedDataList{
+		{owl.NewDirective("form", "title"), ""},
+		{owl.NewDirective("form", "payload"), 1},
+	}, tracker.Executed)
+}
+
+func TestScan_DynamicTypes_ErrorPath(t *testing.T) {
+	ns, _ := createNsForTrackingWithError(errors.New("boom"))
+	resolver, err := owl.New(Drawing{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(t, err)
+
+	err = resolver.Scan(&Drawing{Shape: Square{}})
+	var scanErrors []*owl.ScanError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var se *owl.ScanError
+		if errors.As(e, &se) {
+			scanErrors = append(scanErrors, se)
+		}
+	}
+	assert.Len(t, scanErrors, 3)
+	assert.Equal(t, "Shape.Side", scanErrors[1].Resolver.PathString())
+}
+
+func TestResolve_DynamicTypes_SetByExecutor(t *testing.T) {
+	assert := assert.New(t)
+
+	type Counter struct {
+		N int `owl:"inc"`
+	}
+	type Request struct {
+		Payload any `owl:"payload"`
+	}
+
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("payload", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().Set(reflect.ValueOf(Counter{N: 1}))
+		return nil
+	}))
+	ns.RegisterDirectiveExecutor("inc", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().SetInt(rtm.Value.Elem().Int() + 10)
+		return nil
+	}))
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal(Counter{N: 1}, gotValue.Interface().(*Request).Payload)
+
+	gotValue, err = resolver.Resolve(owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+	assert.Equal(Counter{N: 11}, gotValue.Interface().(*Request).Payload)
+}
+
+func TestResolve_DynamicTypes_ConcreteTypeFunc(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	resolver, err := owl.New(Drawing{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+
+	shapeType := reflect.TypeOf((*Shape)(nil)).Elem()
+	pickRectangle := owl.WithConcreteTypeFunc(func(r *owl.Resolver, ctx context.Context) reflect.Type {
+		if r.Type == shapeType {
+			return reflect.TypeOf(&Rectangle{})
+		}
+		return nil
+	})
+
+	gotValue, err := resolver.Resolve(pickRectangle)
+	assert.NoError(err)
+	assert.Equal(&Rectangle{}, gotValue.Interface().(*Drawing).Shape)
+	assert.Nil(gotValue.Interface().(*Drawing).Payload)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "title"),
+		owl.NewDirective("form", "width"),
+		owl.NewDirective("form", "height"),
+		owl.NewDirective("form", "payload"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// The concrete type must implement the interface.
+	_, err = resolver.Resolve(owl.WithConcreteTypeFunc(func(r *owl.Resolver, ctx context.Context) reflect.Type {
+		return reflect.TypeOf(Rectangle{}) // Area is defined on *Rectangle
+	}))
+	assert.ErrorIs(err, owl.ErrTypeMismatch)
+}
+
+func TestResolve_DynamicTypes_InvalidTag(t *testing.T) {
+	type Invalid struct {
+		Name string `owl:"invalid/name"`
+	}
+	type Request struct {
+		Payload any
+	}
+
+	resolver, err := owl.New(Request{}, owl.WithDynamicTypesEnabled(true))
+	assert.NoError(t, err)
+
+	err = resolver.ResolveTo(&Request{Payload: &Invalid{}})
+	assert.ErrorIs(t, err, owl.ErrInvalidDirectiveName)
+	assert.ErrorContains(t, err, "dynamic type")
+
+	err = resolver.Scan(&Request{Payload: &Invalid{}})
+	assert.ErrorIs(t, err, owl.ErrInvalidDirectiveName)
+}
@@ -13,6 +13,26 @@ var (
 	ErrTypeMismatch         = errors.New("type mismatch")
 	ErrScanNilField         = errors.New("scan nil field")
 	ErrInvalidResolveTarget = errors.New("invalid resolve target")
+	ErrDirectiveNotFound    = errors.New("directive not found")
+	ErrFrozenTree           = errors.New("frozen tree")
+	ErrExecutorPanic        = errors.New("executor panic")
+)
+
+// The sentinel errors below can be returned by the directive executors to
+// control the flow of Resolve and Scan. They are not treated as failures.
+var (
+	// ErrSkipRemainingDirectives skips the remaining directives of the field
+	// in the same phase (see PostOrder). e.g. for "query=a;header=b", the query
+	// executor returns it when a value was found, to skip the header executor.
+	ErrSkipRemainingDirectives = errors.New("skip remaining directives")
+
+	// ErrSkipChildren skips the children of the field, while the remaining
+	// directives of the field still run.
+	ErrSkipChildren = errors.New("skip children")
+
+	// ErrStop stops the whole resolution (or scan) immediately, no more
+	// directives will run. Resolve and Scan return no error for it.
+	ErrStop = errors.New("stop")
 )
 
 func invalidDirectiveName(name string) error {
@@ -62,6 +82,26 @@ func (e *fieldError) AsDirectiveExecutionError() *DirectiveExecutionError {
 	return de
 }
 
+// ExecutorPanicError is the error turned from the panic of an executor, see
+// WithPanicRecovery. It wraps ErrExecutorPanic, and the recovered value if it
+// is an error. A recovered sentinel error, e.g. ErrStop, fails the directive
+// as well, rather than controlling the flow.
+type ExecutorPanicError struct {
+	Value any    // the recovered value
+	Stack []byte // the stack trace of the panicking goroutine
+}
+
+func (e *ExecutorPanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrExecutorPanic, e.Value)
+}
+
+func (e *ExecutorPanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrExecutorPanic, err}
+	}
+	return []error{ErrExecutorPanic}
+}
+
 type DirectiveExecutionError struct {
 	Err error
 	Directive
@@ -0,0 +1,137 @@
+package owl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// exportedResolver is the serializable form of a Resolver, see ExportJSON.
+type exportedResolver struct {
+	Path       string               `json:"path"`
+	Type       string               `json:"type"`
+	Index      []int                `json:"index"`
+	Directives []*exportedDirective `json:"directives,omitempty"`
+
+	// The struct-level directives, see BlankField.
+	PreDirectives  []*exportedDirective `json:"pre_directives,omitempty"`
+	PostDirectives []*exportedDirective `json:"post_directives,omitempty"`
+
+	Children []*exportedResolver `json:"children,omitempty"`
+}
+
+type exportedDirective struct {
+	Name      string   `json:"name"`
+	Argv      []string `json:"argv"`
+	PostOrder bool     `json:"post_order,omitempty"`
+	Executor  bool     `json:"executor"` // whether the executor was found in the namespace
+}
+
+// ExportJSON serializes the resolver tree to indented JSON. Each node carries
+// its path, type, index, directives (with argv), including the struct-level
+// ones, and whether the executor of each directive can be found in the
+// namespace of the tree. It is useful for design reviews and snapshot tests of
+// the struct definitions.
+func (r *Resolver) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(exportResolver(r), "", "  ")
+}
+
+func exportResolver(r *Resolver) *exportedResolver {
+	node := &exportedResolver{
+		Path:  r.PathString(),
+		Type:  r.Type.String(),
+		Index: append([]int{}, r.Index...),
+	}
+	node.Directives = r.exportDirectives(r.Directives)
+	node.PreDirectives = r.exportDirectives(r.PreDirectives)
+	node.PostDirectives = r.exportDirectives(r.PostDirectives)
+	for _, child := range r.Children {
+		node.Children = append(node.Children, exportResolver(child))
+	}
+	return node
+}
+
+func (r *Resolver) exportDirectives(directives []*Directive) []*exportedDirective {
+	var exported []*exportedDirective
+	for _, d := range directives {
+		exported = append(exported, &exportedDirective{
+			Name:      d.Name,
+			Argv:      append([]string{}, d.Argv...),
+			PostOrder: d.PostOrder,
+			Executor:  r.hasExecutor(d.Name),
+		})
+	}
+	return exported
+}
+
+// ExportDOT renders the resolver tree in Graphviz DOT language. The
+// struct-level directives are marked with "(struct)", and the directives whose
+// executors can't be found in the namespace of the tree are marked with
+// "(missing executor)".
+func (r *Resolver) ExportDOT() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("digraph %q {\n", r.Type.String()))
+	sb.WriteString("  node [shape=box];\n")
+	r.exportGraph(func(id int, lines []string) {
+		sb.WriteString(fmt.Sprintf("  n%d [label=%q];\n", id, strings.Join(lines, "\n")))
+	}, func(parentID, childID int) {
+		sb.WriteString(fmt.Sprintf("  n%d -> n%d;\n", parentID, childID))
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ExportMermaid renders the resolver tree as a Mermaid flowchart. The
+// directives are marked in the same way as ExportDOT.
+func (r *Resolver) ExportMermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	r.exportGraph(func(id int, lines []string) {
+		label := strings.ReplaceAll(strings.Join(lines, "<br/>"), `"`, "#quot;")
+		sb.WriteString(fmt.Sprintf("  n%d[\"%s\"]\n", id, label))
+	}, func(parentID, childID int) {
+		sb.WriteString(fmt.Sprintf("  n%d --> n%d\n", parentID, childID))
+	})
+	return sb.String()
+}
+
+// exportGraph visits the tree in depth-first order, and calls node for each
+// resolver and edge for each parent-child pair. The ids are assigned in the
+// visiting order.
+func (r *Resolver) exportGraph(node func(id int, lines []string), edge func(parentID, childID int)) {
+	ids := make(map[*Resolver]int)
+	r.Iterate(func(x *Resolver) error {
+		id := len(ids)
+		ids[x] = id
+
+		lines := []string{x.displayPath(), x.Type.String(), fmt.Sprintf("%v", x.Index)}
+		addLines := func(directives []*Directive, isStruct bool) {
+			for _, d := range directives {
+				line := d.String()
+				if isStruct {
+					line += " (struct)"
+				}
+				if !x.hasExecutor(d.Name) {
+					line += " (missing executor)"
+				}
+				lines = append(lines, line)
+			}
+		}
+		addLines(x.Directives, false)
+		addLines(x.PreDirectives, true)
+		addLines(x.PostDirectives, true)
+
+		node(id, lines)
+		if parentID, ok := ids[x.Parent]; ok && x != r {
+			edge(parentID, id)
+		}
+		return nil
+	})
+}
+
+// hasExecutor reports whether the executor of the directive can be found in
+// the namespace of the tree.
+func (r *Resolver) hasExecutor(name string) bool {
+	ns, _ := r.Context.Value(ckNamespace).(*Namespace)
+	return ns != nil && ns.LookupExecutor(name) != nil
+}
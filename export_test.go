@@ -0,0 +1,166 @@
+package owl_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type ExportForm struct {
+	Token      string `owl:"form=token;header=x-token"`
+	Pagination *Pagination
+}
+
+func newExportFormResolver(t *testing.T) *owl.Resolver {
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(exeNoop))
+	resolver, err := owl.New(ExportForm{}, owl.WithNamespace(ns))
+	assert.NoError(t, err)
+	return resolver
+}
+
+func TestResolver_ExportJSON(t *testing.T) {
+	data, err := newExportFormResolver(t).ExportJSON()
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(data))
+	assert.Equal(t, `{
+  "path": "",
+  "type": "owl_test.ExportForm",
+  "index": [],
+  "children": [
+    {
+      "path": "Token",
+      "type": "string",
+      "index": [
+        0
+      ],
+      "directives": [
+        {
+          "name": "form",
+          "argv": [
+            "token"
+          ],
+          "executor": true
+        },
+        {
+          "name": "header",
+          "argv": [
+            "x-token"
+          ],
+          "executor": false
+        }
+      ]
+    },
+    {
+      "path": "Pagination",
+      "type": "*owl_test.Pagination",
+      "index": [
+        1
+      ],
+      "children": [
+        {
+          "path": "Pagination.Page",
+          "type": "int",
+          "index": [
+            1,
+            0
+          ],
+          "directives": [
+            {
+              "name": "form",
+              "argv": [
+                "page"
+              ],
+              "executor": true
+            }
+          ]
+        },
+        {
+          "path": "Pagination.Size",
+          "type": "int",
+          "index": [
+            1,
+            1
+          ],
+          "directives": [
+            {
+              "name": "form",
+              "argv": [
+                "size"
+              ],
+              "executor": true
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`, string(data))
+}
+
+func TestResolver_ExportDOT(t *testing.T) {
+	assert.Equal(t, `digraph "owl_test.ExportForm" {
+  node [shape=box];
+  n0 [label="(root)\nowl_test.ExportForm\n[]"];
+  n1 [label="Token\nstring\n[0]\nform=token\nheader=x-token (missing executor)"];
+  n0 -> n1;
+  n2 [label="Pagination\n*owl_test.Pagination\n[1]"];
+  n0 -> n2;
+  n3 [label="Pagination.Page\nint\n[1 0]\nform=page"];
+  n2 -> n3;
+  n4 [label="Pagination.Size\nint\n[1 1]\nform=size"];
+  n2 -> n4;
+}
+`, newExportFormResolver(t).ExportDOT())
+}
+
+func TestResolver_ExportMermaid(t *testing.T) {
+	assert.Equal(t, `flowchart TD
+  n0["Pagination<br/>*owl_test.Pagination<br/>[1]"]
+  n1["Pagination.Page<br/>int<br/>[1 0]<br/>form=page"]
+  n0 --> n1
+  n2["Pagination.Size<br/>int<br/>[1 1]<br/>form=size"]
+  n0 --> n2
+`, newExportFormResolver(t).Lookup("Pagination").ExportMermaid())
+
+	type Quoted struct {
+		Name string `owl:"default=\"owl\""`
+	}
+	resolver, err := owl.New(Quoted{})
+	assert.NoError(t, err)
+	assert.Contains(t, resolver.ExportMermaid(), `default=#quot;owl#quot; (missing executor)`)
+}
+
+func TestResolver_Export_StructDirectives(t *testing.T) {
+	assert := assert.New(t)
+	type Form struct {
+		_ struct{} `owl:"oneof=A,B"`
+		A string   `owl:"form=a"`
+		B string   `owl:"form=b"`
+		_ struct{} `owl:"verify"`
+	}
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(exeNoop))
+	ns.RegisterDirectiveExecutor("oneof", owl.DirectiveExecutorFunc(exeNoop))
+	resolver, err := owl.New(Form{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	data, err := resolver.ExportJSON()
+	assert.NoError(err)
+	var exported struct {
+		PreDirectives  []map[string]any `json:"pre_directives"`
+		PostDirectives []map[string]any `json:"post_directives"`
+	}
+	assert.NoError(json.Unmarshal(data, &exported))
+	assert.Equal([]map[string]any{
+		{"name": "oneof", "argv": []any{"A", "B"}, "executor": true},
+	}, exported.PreDirectives)
+	assert.Equal([]map[string]any{
+		{"name": "verify", "argv": []any{}, "executor": false},
+	}, exported.PostDirectives)
+
+	assert.Contains(resolver.ExportDOT(), `n0 [label="(root)\nowl_test.Form\n[]\noneof=A,B (struct)\nverify (struct) (missing executor)"];`)
+	assert.Contains(resolver.ExportMermaid(), `n0["(root)<br/>owl_test.Form<br/>[]<br/>oneof=A,B (struct)<br/>verify (struct) (missing executor)"]`)
+}
@@ -0,0 +1,87 @@
+package owl
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy names a struct field in the paths of the resolvers. See
+// WithNamingStrategy. Builtin strategies are GoFieldName, JSONFieldName and
+// SnakeCaseFieldName. Any function of this signature can be used as a custom
+// naming strategy.
+type NamingStrategy func(field reflect.StructField) string
+
+// GoFieldName names a field by its Go field name, e.g. "CSRFToken". This is
+// the default naming strategy.
+func GoFieldName(field reflect.StructField) string {
+	return field.Name
+}
+
+// JSONFieldName names a field by the name in its json tag, e.g. "csrf_token"
+// for `json:"csrf_token,omitempty"`. Falls back to the Go field name if the
+// name is absent in the tag or the field is ignored by `json:"-"`.
+func JSONFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// SnakeCaseFieldName names a field by converting its Go field name to snake
+// case, e.g. "CSRFToken" -> "csrf_token", "UserID" -> "user_id".
+func SnakeCaseFieldName(field reflect.StructField) string {
+	return toSnakeCase(field.Name)
+}
+
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at an upper letter, which either follows a lower
+			// letter (or digit), or begins a new word after an acronym.
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Name returns the name of the field which the resolver represents, named by
+// the naming strategy of the tree. Returns an empty string for the root.
+func (r *Resolver) Name() string {
+	if r.Field.Name == "" {
+		return ""
+	}
+	return r.namingStrategy()(r.Field)
+}
+
+// NamePath works like Path, but the fields are named by the naming strategy
+// of the tree. See WithNamingStrategy.
+func (r *Resolver) NamePath() []string {
+	var names []string
+	for x := r; x != nil; x = x.Parent {
+		if name := x.Name(); name != "" {
+			names = append(names, name)
+		}
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return names
+}
+
+func (r *Resolver) namingStrategy() NamingStrategy {
+	if r.Context != nil {
+		if naming, ok := r.Context.Value(ckNamingStrategy).(NamingStrategy); ok && naming != nil {
+			return naming
+		}
+	}
+	return GoFieldName
+}
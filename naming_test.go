@@ -0,0 +1,83 @@
+package owl_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type SearchRequest struct {
+	CSRFToken  string `owl:"form=csrf_token" json:"token"`
+	Pagination *struct {
+		PageIndex int `owl:"form=page" json:"page,omitempty"`
+		PageSize  int `owl:"form=size" json:"-"`
+	} `json:"pagination"`
+}
+
+func TestBuiltinNamingStrategies(t *testing.T) {
+	typ := reflect.TypeOf(SearchRequest{})
+	field, _ := typ.FieldByName("CSRFToken")
+	assert.Equal(t, "CSRFToken", owl.GoFieldName(field))
+	assert.Equal(t, "token", owl.JSONFieldName(field))
+	assert.Equal(t, "csrf_token", owl.SnakeCaseFieldName(field))
+
+	for name, expected := range map[string]string{
+		"Name":        "name",
+		"UserID":      "user_id",
+		"HTTPServer2": "http_server2",
+		"PageSize":    "page_size",
+		"A":           "a",
+	} {
+		assert.Equal(t, expected, owl.SnakeCaseFieldName(reflect.StructField{Name: name}))
+	}
+}
+
+func TestWithNamingStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	resolver, err := owl.New(SearchRequest{}, owl.WithNamingStrategy(owl.JSONFieldName))
+	assert.NoError(err)
+
+	page := resolver.Lookup("pagination.page")
+	assert.NotNil(page)
+	assert.Equal("PageIndex", page.Field.Name)
+	assert.Equal([]string{"Pagination", "PageIndex"}, page.Path)
+	assert.Equal([]string{"pagination", "page"}, page.NamePath())
+	assert.Equal("pagination.page", page.PathString())
+	assert.Equal("pagination.PageSize", resolver.Lookup("pagination.PageSize").PathString())
+	assert.Nil(resolver.Lookup("Pagination.PageIndex"))
+
+	found, err := resolver.Select("pagination.*")
+	assert.NoError(err)
+	assert.Equal([]string{"pagination.page", "pagination.PageSize"}, pathsOf(found))
+
+	// Custom naming strategy.
+	resolver, err = owl.New(SearchRequest{}, owl.WithNamingStrategy(func(field reflect.StructField) string {
+		return strings.ToUpper(field.Name)
+	}))
+	assert.NoError(err)
+	assert.Equal("PAGINATION.PAGESIZE", resolver.Lookup("PAGINATION.PAGESIZE").PathString())
+
+	// The cached tree is not affected.
+	resolver, err = owl.New(SearchRequest{})
+	assert.NoError(err)
+	assert.Equal("Pagination.PageIndex", resolver.Lookup("Pagination.PageIndex").PathString())
+}
+
+func TestWithNamingStrategy_Errors(t *testing.T) {
+	ns, _ := createNsForTracking()
+	resolver, err := owl.New(SearchRequest{}, owl.WithNamespace(ns), owl.WithNamingStrategy(owl.SnakeCaseFieldName))
+	assert.NoError(t, err)
+
+	err = resolver.Scan(SearchRequest{})
+	assert.ErrorIs(t, err, owl.ErrScanNilField)
+	assert.ErrorContains(t, err, `scan field "pagination.page_index (int)" failed`)
+
+	resolver, err = owl.New(SearchRequest{}, owl.WithNamingStrategy(owl.SnakeCaseFieldName))
+	assert.NoError(t, err)
+	_, err = resolver.Resolve()
+	assert.ErrorContains(t, err, `resolve field "csrf_token (string)" failed`)
+}
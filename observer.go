@@ -0,0 +1,143 @@
+package owl
+
+import (
+	"context"
+	"time"
+)
+
+// Operation tells which operation an Observer is observing.
+type Operation string
+
+const (
+	OpResolve Operation = "resolve" // Resolve, ResolveTo and the alike
+	OpScan    Operation = "scan"    // Scan and ScanContext
+)
+
+// Observer observes the resolutions and scans, see WithObserver. It's useful
+// for tracing, e.g. to create a span for each field and directive, or logging
+// the slow directives. The callbacks are called synchronously and properly
+// nested, e.g. a FieldLeave always follows its FieldEnter, even if stopped
+// halfway. Thus a stack can be used to maintain the spans. The resolutions of
+// the values held by the interface fields (see WithDynamicTypesEnabled) are
+// also observed, nested in the fields.
+type Observer interface {
+	// ResolutionStart is called before resolving (or scanning) a tree.
+	ResolutionStart(ctx context.Context, r *Resolver, op Operation)
+
+	// ResolutionEnd is called after resolving (or scanning) a tree, with the
+	// time elapsed and the error occurred.
+	ResolutionEnd(ctx context.Context, r *Resolver, op Operation, elapsed time.Duration, err error)
+
+	// FieldEnter is called on entering a field, before running its directives.
+	FieldEnter(ctx context.Context, r *Resolver)
+
+	// FieldLeave is called on leaving a field, after its children.
+	FieldLeave(ctx context.Context, r *Resolver)
+
+	// DirectiveStart is called before running a directive.
+	DirectiveStart(ctx context.Context, r *Resolver, d *Directive)
+
+	// DirectiveEnd is called after running a directive, with the time elapsed
+	// and the error returned by the executor.
+	DirectiveEnd(ctx context.Context, r *Resolver, d *Directive, elapsed time.Duration, err error)
+}
+
+// ObserverFuncs is an adapter to build an Observer from functions. The nil
+// functions are ignored.
+type ObserverFuncs struct {
+	OnResolutionStart func(ctx context.Context, r *Resolver, op Operation)
+	OnResolutionEnd   func(ctx context.Context, r *Resolver, op Operation, elapsed time.Duration, err error)
+	OnFieldEnter      func(ctx context.Context, r *Resolver)
+	OnFieldLeave      func(ctx context.Context, r *Resolver)
+	OnDirectiveStart  func(ctx context.Context, r *Resolver, d *Directive)
+	OnDirectiveEnd    func(ctx context.Context, r *Resolver, d *Directive, elapsed time.Duration, err error)
+}
+
+func (f ObserverFuncs) ResolutionStart(ctx context.Context, r *Resolver, op Operation) {
+	if f.OnResolutionStart != nil {
+		f.OnResolutionStart(ctx, r, op)
+	}
+}
+
+func (f ObserverFuncs) ResolutionEnd(ctx context.Context, r *Resolver, op Operation, elapsed time.Duration, err error) {
+	if f.OnResolutionEnd != nil {
+		f.OnResolutionEnd(ctx, r, op, elapsed, err)
+	}
+}
+
+func (f ObserverFuncs) FieldEnter(ctx context.Context, r *Resolver) {
+	if f.OnFieldEnter != nil {
+		f.OnFieldEnter(ctx, r)
+	}
+}
+
+func (f ObserverFuncs) FieldLeave(ctx context.Context, r *Resolver) {
+	if f.OnFieldLeave != nil {
+		f.OnFieldLeave(ctx, r)
+	}
+}
+
+func (f ObserverFuncs) DirectiveStart(ctx context.Context, r *Resolver, d *Directive) {
+	if f.OnDirectiveStart != nil {
+		f.OnDirectiveStart(ctx, r, d)
+	}
+}
+
+func (f ObserverFuncs) DirectiveEnd(ctx context.Context, r *Resolver, d *Directive, elapsed time.Duration, err error) {
+	if f.OnDirectiveEnd != nil {
+		f.OnDirectiveEnd(ctx, r, d, elapsed, err)
+	}
+}
+
+// observerOf returns the observer bound by WithObserver, nil if not set.
+func observerOf(ctx context.Context, r *Resolver) Observer {
+	observer, _ := lookupOption(ctx, r, ckObserver).(Observer)
+	return observer
+}
+
+// observe wraps the visitor to notify the observer bound by WithObserver of
+// the resolution and the fields. Returns the function to call with the result
+// once the walk is done.
+func (r *Resolver) observe(ctx context.Context, op Operation, v Visitor) (Visitor, func(error)) {
+	observer := observerOf(ctx, r)
+	if observer == nil {
+		return v, func(error) {}
+	}
+
+	observer.ResolutionStart(ctx, r, op)
+	start := time.Now()
+	ov := &observedVisitor{Visitor: v, ctx: ctx, observer: observer}
+	return ov, func(err error) {
+		ov.unwind()
+		observer.ResolutionEnd(ctx, r, op, time.Since(start), ignoreStop(err))
+	}
+}
+
+// observedVisitor notifies the observer on entering and leaving the fields.
+type observedVisitor struct {
+	Visitor
+	ctx      context.Context
+	observer Observer
+	entered  []*Resolver // the fields not left yet
+}
+
+func (v *observedVisitor) Enter(r *Resolver, depth int) (WalkAction, error) {
+	v.observer.FieldEnter(v.ctx, r)
+	v.entered = append(v.entered, r)
+	return v.Visitor.Enter(r, depth)
+}
+
+func (v *observedVisitor) Leave(r *Resolver, depth int) (WalkAction, error) {
+	action, err := v.Visitor.Leave(r, depth)
+	v.entered = v.entered[:len(v.entered)-1]
+	v.observer.FieldLeave(v.ctx, r)
+	return action, err
+}
+
+// unwind leaves the fields which were not left, since the walk was stopped.
+func (v *observedVisitor) unwind() {
+	for i := len(v.entered) - 1; i >= 0; i-- {
+		v.observer.FieldLeave(v.ctx, v.entered[i])
+	}
+	v.entered = nil
+}
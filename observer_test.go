@@ -0,0 +1,116 @@
+package owl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+// observerRecorder records the events in a flat list.
+type observerRecorder struct {
+	events []string
+}
+
+func (o *observerRecorder) ResolutionStart(ctx context.Context, r *owl.Resolver, op owl.Operation) {
+	o.events = append(o.events, fmt.Sprintf("start %s %v", op, r.Type))
+}
+
+func (o *observerRecorder) ResolutionEnd(ctx context.Context, r *owl.Resolver, op owl.Operation, elapsed time.Duration, err error) {
+	o.events = append(o.events, fmt.Sprintf("end %s %v: %v", op, r.Type, err))
+}
+
+func (o *observerRecorder) FieldEnter(ctx context.Context, r *owl.Resolver) {
+	o.events = append(o.events, "enter "+r.PathString())
+}
+
+func (o *observerRecorder) FieldLeave(ctx context.Context, r *owl.Resolver) {
+	o.events = append(o.events, "leave "+r.PathString())
+}
+
+func (o *observerRecorder) DirectiveStart(ctx context.Context, r *owl.Resolver, d *owl.Directive) {
+	o.events = append(o.events, "run "+d.String())
+}
+
+func (o *observerRecorder) DirectiveEnd(ctx context.Context, r *owl.Resolver, d *owl.Directive, elapsed time.Duration, err error) {
+	o.events = append(o.events, fmt.Sprintf("done %s: %v", d, err))
+}
+
+func TestWithObserver(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	observer := &observerRecorder{}
+	_, err = resolver.Resolve(owl.WithObserver(observer), owl.WithExcludedPaths("User.Gender", "User.Birthday"))
+	assert.NoError(err)
+	assert.Equal([]string{
+		"start resolve owl_test.UserSignUpForm",
+		"enter ",
+		"enter User",
+		"run form=user",
+		"done form=user: <nil>",
+		"enter User.Name",
+		"run form=name",
+		"done form=name: <nil>",
+		"leave User.Name",
+		"enter User.Gender",
+		"leave User.Gender",
+		"enter User.Birthday",
+		"leave User.Birthday",
+		"leave User",
+		"enter CSRFToken",
+		"run form=csrf_token",
+		"done form=csrf_token: <nil>",
+		"leave CSRFToken",
+		"leave ",
+		"end resolve owl_test.UserSignUpForm: <nil>",
+	}, observer.events)
+
+	// Set in New, observes Scan as well. The fields are left even if stopped.
+	ns, _ = createNsForTrackingWithError(errors.New("TestWithObserver"))
+	observer = &observerRecorder{}
+	resolver, err = owl.New(UserSignUpForm{}, owl.WithNamespace(ns), owl.WithObserver(observer))
+	assert.NoError(err)
+	assert.Error(resolver.Scan(&UserSignUpForm{}, owl.WithFailFast(true)))
+	assert.Equal([]string{
+		"start scan owl_test.UserSignUpForm",
+		"enter ",
+		"enter User",
+		"run form=user",
+		"done form=user: TestWithObserver",
+		"leave User",
+		"leave ",
+		"end scan owl_test.UserSignUpForm: scan field \"User (owl_test.User)\" failed: execute directive \"form\" with args [user] failed: TestWithObserver",
+	}, observer.events)
+}
+
+func TestObserverFuncs(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+
+	type Canvas struct {
+		Shape any `owl:"form=shape"`
+	}
+	resolver, err := owl.New(Canvas{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+
+	var resolutions []string
+	var elapsed time.Duration
+	observer := owl.ObserverFuncs{
+		OnResolutionStart: func(ctx context.Context, r *owl.Resolver, op owl.Operation) {
+			resolutions = append(resolutions, r.Type.String())
+		},
+		OnDirectiveEnd: func(ctx context.Context, r *owl.Resolver, d *owl.Directive, took time.Duration, err error) {
+			elapsed += took
+		},
+	}
+	assert.NoError(resolver.ResolveTo(&Canvas{Shape: &Pagination{}}, owl.WithObserver(observer)))
+	assert.Equal([]string{"owl_test.Canvas", "owl_test.Pagination"}, resolutions) // the dynamic types are observed as well
+	assert.Greater(elapsed, time.Duration(0))
+}
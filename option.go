@@ -32,6 +32,150 @@ func WithNestedDirectivesEnabled(resolve bool) Option {
 	return WithValue(ckResolveNestedDirectives, resolve)
 }
 
+// WithLazyAllocation controls whether to keep the nil pointers of nested
+// structs as nil when nothing has been resolved into them. The default value is
+// false, which means a nil pointer is always instantiated before resolving its
+// children. When set to true, a pointer instantiated by Resolve will be reset
+// to nil after its subtree resolves, if no directive set a value into it, i.e.
+// the directives of its descendants, and the post-order ones of itself. Thus
+// "absent" can be told apart from "present". A directive sets a value if it
+// changes the field value, or its executor calls DirectiveRuntime.MarkValueSet,
+// which is required to tell a zero value set to a zero field, e.g. false to a
+// bool. The struct-level directives don't count. The value set in New() will be
+// overridden by the value set in Resolve().
+func WithLazyAllocation(lazy bool) Option {
+	return WithValue(ckLazyAllocation, lazy)
+}
+
+// WithDynamicTypesEnabled controls whether to resolve the interface fields by
+// the dynamic types of their values. The default value is false. When set to
+// true, if an interface field holds a struct (or a pointer to a struct), a
+// resolver tree will be built from the dynamic type and the directives in it
+// will be executed as well. The nested directives rule also applies to the
+// interface fields, see WithNestedDirectivesEnabled. The value set in New()
+// will be overridden by the value set in Resolve() or Scan(). NOTE: the
+// interface fields without any directives are only kept in the resolver tree
+// when it is set to true in New(), otherwise they are skipped like the other
+// untagged fields.
+func WithDynamicTypesEnabled(enabled bool) Option {
+	return WithValue(ckDynamicTypes, enabled)
+}
+
+// WithConcreteTypeFunc sets the function to pick the concrete type to
+// instantiate for a nil interface field during Resolve. It only takes effect
+// when WithDynamicTypesEnabled is true. See ConcreteTypeFunc for more details.
+func WithConcreteTypeFunc(fn ConcreteTypeFunc) Option {
+	return WithValue(ckConcreteTypeFunc, fn)
+}
+
+// WithNamingStrategy sets the naming strategy of the resolver tree. It affects
+// Resolver.PathString, Resolver.Lookup, Resolver.Select and the paths shown in
+// ResolveError and ScanError. The default naming strategy is GoFieldName. It
+// only takes effect in New(), since the strategy is bound to the tree.
+func WithNamingStrategy(naming NamingStrategy) Option {
+	return WithValue(ckNamingStrategy, naming)
+}
+
+// WithTreeCache sets the cache to load and store the built resolver trees,
+// instead of the global one (see UseTreeCache). It only takes effect in New().
+func WithTreeCache(cache TreeCache) Option {
+	return WithValue(ckTreeCache, cache)
+}
+
+// WithExcludedFields excludes the fields matching the given patterns from the
+// resolver tree, along with their subtrees. It works like tagging the fields
+// with ExcludeMarker, i.e. `owl:"-"`, and is useful for the types you can't
+// edit. See Resolver.Select for the syntax of the patterns. It only takes
+// effect in New().
+func WithExcludedFields(patterns ...string) Option {
+	return WithValue(ckExcludedFields, patterns)
+}
+
+// WithOverlays sets the tag overlays to apply while building the resolver
+// tree, instead of the global overlays (see RegisterOverlay). It only takes
+// effect in New().
+func WithOverlays(overlays *OverlaySet) Option {
+	return WithValue(ckOverlays, overlays)
+}
+
+// WithFailFast controls whether to stop at the first failed field. The default
+// value is true for Resolve (and ResolveTo), and false for Scan. When set to
+// false, the resolution keeps going after a field fails, while the subtree of
+// the failed field is skipped, and all the field errors are returned together,
+// combined by errors.Join. When set to true, Scan stops at the first error,
+// which is useful when only a yes/no answer is needed. The value set in New()
+// will be overridden by the value set in Resolve() or Scan().
+func WithFailFast(failFast bool) Option {
+	return WithValue(ckFailFast, failFast)
+}
+
+// WithMaxErrors caps the number of the errors collected by Scan, or by Resolve
+// with WithFailFast(false). Once the cap is reached, the iteration stops. A
+// non-positive value means no cap, which is the default. The value set in
+// New() will be overridden by the value set in Resolve() or Scan().
+func WithMaxErrors(n int) Option {
+	return WithValue(ckMaxErrors, n)
+}
+
+// WithOnlyPaths selects the fields to resolve or scan, which match the given
+// patterns, along with their subtrees. All the other fields are left untouched
+// and their directives are not executed, including the ones of the ancestors
+// of the selected fields, which are only passed through. The value held by an
+// interface field is selected as a whole, see WithDynamicTypesEnabled. See
+// Resolver.Select for the syntax of the patterns. Ex:
+//
+//	resolver.ResolveTo(req, owl.WithOnlyPaths("Pagination", "Sort"))
+//
+// The value set in New() will be overridden by the value set in Resolve() or
+// Scan().
+func WithOnlyPaths(patterns ...string) Option {
+	return WithValue(ckOnlyPaths, patterns)
+}
+
+// WithExcludedPaths skips the fields matching the given patterns, along with
+// their subtrees, while resolving or scanning. Unlike WithExcludedFields, the
+// fields are kept in the resolver tree. It works together with WithOnlyPaths,
+// i.e. the excluded fields are skipped even if selected. See Resolver.Select
+// for the syntax of the patterns. The value set in New() will be overridden by
+// the value set in Resolve() or Scan().
+func WithExcludedPaths(patterns ...string) Option {
+	return WithValue(ckExcludedPaths, patterns)
+}
+
+// WithTransaction controls whether ResolveTo resolves the value atomically.
+// The default value is false, which means the fields are resolved into the
+// target directly, and the target can be left half-populated when a field
+// fails. When set to true, ResolveTo resolves into a copy of the target, and
+// only commits the copy to the target on success, so a live object, e.g. a
+// config reloaded on the fly, can be resolved safely. Along the resolver tree,
+// the nested structs referred by the pointers are copied as well, and replaced
+// by the copies on commit. So are the values held by the interface fields,
+// along the subtrees of their dynamic types (see WithDynamicTypesEnabled).
+// While the other values, e.g. maps and slices, are shared. The value set in
+// New() will be overridden by the value set in ResolveTo().
+func WithTransaction(enabled bool) Option {
+	return WithValue(ckTransaction, enabled)
+}
+
+// WithObserver sets the observer to be notified while resolving or scanning,
+// e.g. on entering and leaving the fields, and running the directives. See
+// Observer for more details. The value set in New() will be overridden by the
+// value set in Resolve() or Scan().
+func WithObserver(observer Observer) Option {
+	return WithValue(ckObserver, observer)
+}
+
+// WithPanicRecovery controls whether to recover the panics of the executors.
+// The default value is false, which means a panicking executor crashes the
+// caller. When set to true, the panic is turned into a DirectiveExecutionError
+// of the directive, which wraps an ExecutorPanicError carrying the recovered
+// value and the stack trace, i.e. errors.Is(err, ErrExecutorPanic) reports
+// true. The value set in New() will be overridden by the value set in
+// Resolve() or Scan().
+func WithPanicRecovery(enabled bool) Option {
+	return WithValue(ckPanicRecovery, enabled)
+}
+
 // WithValue binds a value to the context.
 //
 // When used in New(), the value is bound to Resolver.Context.
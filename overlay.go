@@ -0,0 +1,276 @@
+package owl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	defaultOverlays = NewOverlaySet()
+	emptyOverlays   = NewOverlaySet()
+
+	lastOverlaySetID uint64
+)
+
+// RegisterOverlay registers a tag overlay globally, see
+// OverlaySet.RegisterOverlay.
+func RegisterOverlay(structValue any, path string, tag string, mode OverlayMode) error {
+	return defaultOverlays.RegisterOverlay(structValue, path, tag, mode)
+}
+
+// OverlayMode tells how an overlay tag works with the struct tag of the field.
+type OverlayMode int
+
+const (
+	// OverlayMerge merges the overlay tag on top of the struct tag. The
+	// directives of the same names are replaced in place, and the others are
+	// appended.
+	OverlayMerge OverlayMode = iota
+
+	// OverlayReplace replaces the struct tag with the overlay tag.
+	OverlayReplace
+)
+
+// OverlaySet is a collection of tag overlays, which attach directives to the
+// fields of the struct types you can't add tags to, e.g. protobuf-generated
+// types, or types from other modules. It also holds the type-level default
+// directives, see RegisterTypeDirectives. The overlays are applied while
+// building the resolver trees, see WithOverlays. It is safe for concurrent use.
+type OverlaySet struct {
+	mu      sync.RWMutex
+	id      uint64
+	version uint64
+	fields  map[reflect.Type]map[string]*fieldOverlay // type -> field path -> overlay
+	types   map[reflect.Type][]*Directive             // type-level default directives
+	kinds   map[reflect.Kind][]*Directive             // kind-level default directives
+}
+
+type fieldOverlay struct {
+	mode OverlayMode
+	tag  *fieldTag
+}
+
+// NewOverlaySet creates an empty collection of tag overlays.
+func NewOverlaySet() *OverlaySet {
+	return &OverlaySet{
+		id:     atomic.AddUint64(&lastOverlaySetID, 1),
+		fields: make(map[reflect.Type]map[string]*fieldOverlay),
+		types:  make(map[reflect.Type][]*Directive),
+		kinds:  make(map[reflect.Kind][]*Directive),
+	}
+}
+
+// RegisterOverlay attaches the tag to a field of the struct type. The path is
+// the dotted Go field path relative to the struct type, e.g. "Auth.Token". The
+// overlay applies wherever the struct type appears in a resolver tree. When
+// multiple overlays match the same field, e.g. one registered to the type of
+// the field's parent and one to the root type, the overlay registered to the
+// outer type is applied later. The tag can also be ExcludeMarker, i.e. "-", to
+// exclude the field, or "-name" to suppress a type-level default directive.
+// Registering to the same field again replaces the former overlay. Ex:
+//
+//	overlays.RegisterOverlay(sdk.Config{}, "Auth.Token", "env=SDK_TOKEN", owl.OverlayReplace)
+func (s *OverlaySet) RegisterOverlay(structValue any, path string, tag string, mode OverlayMode) error {
+	typ, err := reflectStructType(structValue)
+	if err != nil {
+		return err
+	}
+	if err := validateFieldPath(typ, path); err != nil {
+		return fmt.Errorf("invalid overlay for %v: %w", typ, err)
+	}
+
+	parsed, err := parseFieldTag(tag)
+	if err != nil {
+		return fmt.Errorf("invalid overlay for %v: field %q: parse directives (tag): %w", typ, path, err)
+	}
+	overlay := &fieldOverlay{mode: mode, tag: parsed}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fields[typ] == nil {
+		s.fields[typ] = make(map[string]*fieldOverlay)
+	}
+	s.fields[typ][path] = overlay
+	s.version++
+	return nil
+}
+
+// OverlaySpec is the serializable form of the overlays of a struct type. The
+// overlays can be loaded from a JSON file by LoadJSON. For the other formats,
+// e.g. YAML, decode the file into []OverlaySpec and call Load. Ex:
+//
+//	# overlays.yaml
+//	- type: github.com/example/sdk.Config
+//	  mode: replace
+//	  fields:
+//	    Auth.Token: env=SDK_TOKEN
+type OverlaySpec struct {
+	Type   string            `json:"type" yaml:"type"`                     // full name, e.g. "github.com/example/sdk.Config", or short name, e.g. "sdk.Config"
+	Mode   string            `json:"mode,omitempty" yaml:"mode,omitempty"` // "merge" (default) or "replace"
+	Fields map[string]string `json:"fields" yaml:"fields"`                 // field path -> tag
+}
+
+// Load registers the overlays described by the specs. Since the types can't be
+// resolved from their names at runtime, the struct types referred by the
+// specs must be given, in the same forms as New accepts.
+func (s *OverlaySet) Load(specs []OverlaySpec, types ...any) error {
+	known := make(map[string]reflect.Type)
+	for _, structValue := range types {
+		typ, err := reflectStructType(structValue)
+		if err != nil {
+			return err
+		}
+		known[typ.PkgPath()+"."+typ.Name()] = typ
+		known[typ.String()] = typ
+	}
+
+	for _, spec := range specs {
+		typ, ok := known[spec.Type]
+		if !ok {
+			return fmt.Errorf("%w: unknown overlay type %q", ErrUnsupportedType, spec.Type)
+		}
+		mode, err := parseOverlayMode(spec.Mode)
+		if err != nil {
+			return fmt.Errorf("invalid overlay for %v: %w", typ, err)
+		}
+		for path, tag := range spec.Fields {
+			if err := s.RegisterOverlay(typ, path, tag, mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadJSON registers the overlays decoded from a JSON array of OverlaySpec.
+// See Load for the types.
+func (s *OverlaySet) LoadJSON(r io.Reader, types ...any) error {
+	var specs []OverlaySpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return fmt.Errorf("decode overlays: %w", err)
+	}
+	return s.Load(specs, types...)
+}
+
+func parseOverlayMode(mode string) (OverlayMode, error) {
+	switch mode {
+	case "", "merge":
+		return OverlayMerge, nil
+	case "replace":
+		return OverlayReplace, nil
+	}
+	return OverlayMerge, fmt.Errorf("unknown overlay mode %q", mode)
+}
+
+// fingerprint identifies the current state of the overlays, which is a part of
+// the cache key of the trees. Returns an empty string if no overlays.
+// NOTE: s.mu must be held.
+func (s *OverlaySet) fingerprint() string {
+	if len(s.fields) == 0 && len(s.types) == 0 && len(s.kinds) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d@%d", s.id, s.version)
+}
+
+// match returns the overlays of the field, whose parent is the given resolver,
+// from the innermost type to the outermost type.
+// NOTE: s.mu must be held.
+func (s *OverlaySet) match(parent *Resolver, field reflect.StructField) []*fieldOverlay {
+	if len(s.fields) == 0 {
+		return nil
+	}
+
+	var matched []*fieldOverlay
+	path := append(append([]string{}, parent.Path...), field.Name)
+	for x := parent; x != nil; x = x.Parent {
+		typ := x.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if overlay := s.fields[typ][strings.Join(path[len(x.Path):], ".")]; overlay != nil {
+			matched = append(matched, overlay)
+		}
+	}
+	return matched
+}
+
+// apply applies the overlay to the parsed tag of a field.
+func (o *fieldOverlay) apply(tag *fieldTag) *fieldTag {
+	switch {
+	case o.tag.excluded:
+		return &fieldTag{excluded: true}
+	case o.mode == OverlayReplace:
+		return o.tag
+	case tag.excluded:
+		return tag // nothing to merge on top of
+	}
+
+	merged := &fieldTag{
+		directives: append([]*Directive{}, tag.directives...),
+		suppressed: append(append([]string{}, tag.suppressed...), o.tag.suppressed...),
+	}
+	for _, d := range o.tag.directives {
+		if i := indexOfDirective(merged.directives, d.Name); i >= 0 {
+			merged.directives[i] = d
+		} else {
+			merged.directives = append(merged.directives, d)
+		}
+	}
+	return merged
+}
+
+func indexOfDirective(directives []*Directive, name string) int {
+	for i, d := range directives {
+		if d.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateFieldPath checks whether the dotted path refers to a field of the
+// struct type, through exported fields only.
+func validateFieldPath(typ reflect.Type, path string) error {
+	for _, name := range strings.Split(path, ".") {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return fmt.Errorf("field %q: %v is not a struct", path, typ)
+		}
+		field, ok := directField(typ, name)
+		if !ok || !field.IsExported() {
+			return fmt.Errorf("field %q: no such exported field %q in %v", path, name, typ)
+		}
+		typ = field.Type
+	}
+	return nil
+}
+
+// directField finds a field declared by the struct type, i.e. the promoted
+// fields of the embedded structs are excluded.
+func directField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Name == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// overlaySet returns the overlays bound to the context, or the global ones.
+func overlaySet(ctx context.Context) *OverlaySet {
+	if overlays, ok := ctx.Value(ckOverlays).(*OverlaySet); ok {
+		if overlays == nil {
+			return emptyOverlays
+		}
+		return overlays
+	}
+	return defaultOverlays
+}
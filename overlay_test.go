@@ -0,0 +1,179 @@
+package owl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+// ThirdPartyAuth and ThirdPartyConfig simulate the types from other modules,
+// which we can't add tags to.
+type ThirdPartyAuth struct {
+	Token  string
+	Secret string `owl:"form=secret"`
+}
+
+type ThirdPartyConfig struct {
+	Endpoint string `owl:"form=endpoint;default=localhost"`
+	Auth     *ThirdPartyAuth
+	Debug    bool
+}
+
+type AppConfig struct {
+	Name   string `owl:"form=name"`
+	Config ThirdPartyConfig
+}
+
+func TestOverlaySet_RegisterOverlay(t *testing.T) {
+	assert := assert.New(t)
+	overlays := owl.NewOverlaySet()
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyConfig{}, "Auth.Token", "env=TOKEN", owl.OverlayMerge))
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyConfig{}, "Endpoint", "env=ENDPOINT;default=example.com", owl.OverlayMerge))
+	assert.NoError(overlays.RegisterOverlay(&ThirdPartyAuth{}, "Secret", "env=SECRET", owl.OverlayReplace))
+	assert.NoError(overlays.RegisterOverlay(AppConfig{}, "Config.Debug", "form=debug", owl.OverlayMerge))
+
+	resolver, err := owl.New(AppConfig{}, owl.WithOverlays(overlays))
+	assert.NoError(err)
+
+	assert.Equal([]*owl.Directive{owl.NewDirective("env", "TOKEN")}, resolver.Lookup("Config.Auth.Token").Directives)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "endpoint"),
+		owl.NewDirective("default", "example.com"),
+		owl.NewDirective("env", "ENDPOINT"),
+	}, resolver.Lookup("Config.Endpoint").Directives)
+	assert.Equal([]*owl.Directive{owl.NewDirective("env", "SECRET")}, resolver.Lookup("Config.Auth.Secret").Directives)
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "debug")}, resolver.Lookup("Config.Debug").Directives)
+
+	// Without overlays.
+	resolver, err = owl.New(AppConfig{})
+	assert.NoError(err)
+	assert.Nil(resolver.Lookup("Config.Auth.Token"))
+	assert.Nil(resolver.Lookup("Config.Debug"))
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "secret")}, resolver.Lookup("Config.Auth.Secret").Directives)
+}
+
+func TestOverlaySet_Precedence(t *testing.T) {
+	overlays := owl.NewOverlaySet()
+	assert.NoError(t, overlays.RegisterOverlay(ThirdPartyAuth{}, "Token", "env=INNER;required", owl.OverlayMerge))
+	assert.NoError(t, overlays.RegisterOverlay(AppConfig{}, "Config.Auth.Token", "env=OUTER", owl.OverlayMerge))
+
+	resolver, err := owl.New(AppConfig{}, owl.WithOverlays(overlays))
+	assert.NoError(t, err)
+	assert.Equal(t, []*owl.Directive{
+		owl.NewDirective("env", "OUTER"),
+		owl.NewDirective("required"),
+	}, resolver.Lookup("Config.Auth.Token").Directives)
+}
+
+func TestOverlaySet_Exclude(t *testing.T) {
+	overlays := owl.NewOverlaySet()
+	assert.NoError(t, overlays.RegisterOverlay(ThirdPartyConfig{}, "Auth", "-", owl.OverlayMerge))
+
+	resolver, err := owl.New(AppConfig{}, owl.WithOverlays(overlays))
+	assert.NoError(t, err)
+	assert.Nil(t, resolver.Lookup("Config.Auth"))
+	assert.NotNil(t, resolver.Lookup("Config.Endpoint"))
+
+	// Revive an excluded field by replacing.
+	type Request struct {
+		Hidden string `owl:"-"`
+	}
+	assert.NoError(t, overlays.RegisterOverlay(Request{}, "Hidden", "form=hidden", owl.OverlayMerge))
+	resolver, err = owl.New(Request{}, owl.WithOverlays(overlays))
+	assert.NoError(t, err)
+	assert.Nil(t, resolver.Lookup("Hidden"))
+
+	assert.NoError(t, overlays.RegisterOverlay(Request{}, "Hidden", "form=hidden", owl.OverlayReplace))
+	resolver, err = owl.New(Request{}, owl.WithOverlays(overlays))
+	assert.NoError(t, err)
+	assert.NotNil(t, resolver.Lookup("Hidden"))
+}
+
+func TestOverlaySet_CacheKey(t *testing.T) {
+	assert := assert.New(t)
+	cache := owl.NewLRUTreeCache(0)
+	overlays := owl.NewOverlaySet()
+
+	_, err := owl.New(ThirdPartyAuth{}, owl.WithTreeCache(cache), owl.WithOverlays(overlays))
+	assert.NoError(err)
+	_, err = owl.New(ThirdPartyAuth{}, owl.WithTreeCache(cache))
+	assert.NoError(err)
+	assert.Equal(owl.TreeCacheStats{Hits: 1, Misses: 1, Size: 1}, cache.Stats(), "empty overlays share the cache")
+
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyAuth{}, "Token", "env=TOKEN", owl.OverlayMerge))
+	r1, err := owl.New(ThirdPartyAuth{}, owl.WithTreeCache(cache), owl.WithOverlays(overlays))
+	assert.NoError(err)
+	assert.NotNil(r1.Lookup("Token"))
+	assert.Equal(2, cache.Stats().Size)
+
+	// Registering new overlays changes the fingerprint.
+	assert.NoError(overlays.RegisterOverlay(ThirdPartyAuth{}, "Token", "env=API_TOKEN", owl.OverlayMerge))
+	r2, err := owl.New(ThirdPartyAuth{}, owl.WithTreeCache(cache), owl.WithOverlays(overlays))
+	assert.NoError(err)
+	assert.Equal(owl.NewDirective("env", "API_TOKEN"), r2.Lookup("Token").GetDirective("env"))
+	assert.Equal(3, cache.Stats().Size)
+
+	// A nil set means no overlays.
+	r3, err := owl.New(ThirdPartyAuth{}, owl.WithTreeCache(cache), owl.WithOverlays(nil))
+	assert.NoError(err)
+	assert.Nil(r3.Lookup("Token"))
+}
+
+func TestOverlaySet_RegisterOverlay_Errors(t *testing.T) {
+	overlays := owl.NewOverlaySet()
+	assert.ErrorIs(t, overlays.RegisterOverlay(123, "Token", "env=TOKEN", owl.OverlayMerge), owl.ErrUnsupportedType)
+	assert.ErrorContains(t, overlays.RegisterOverlay(ThirdPartyAuth{}, "Missing", "env=TOKEN", owl.OverlayMerge), "no such exported field")
+	assert.ErrorContains(t, overlays.RegisterOverlay(ThirdPartyAuth{}, "Token.Length", "env=TOKEN", owl.OverlayMerge), "is not a struct")
+	assert.ErrorIs(t, overlays.RegisterOverlay(ThirdPartyAuth{}, "Token", "env=A;env=B", owl.OverlayMerge), owl.ErrDuplicateDirective)
+	assert.ErrorIs(t, overlays.RegisterOverlay(ThirdPartyAuth{}, "Token", "in/valid", owl.OverlayMerge), owl.ErrInvalidDirectiveName)
+}
+
+func TestRegisterOverlay(t *testing.T) {
+	type GlobalOverlaid struct {
+		Token string
+	}
+	assert.NoError(t, owl.RegisterOverlay(GlobalOverlaid{}, "Token", "env=TOKEN", owl.OverlayMerge))
+
+	resolver, err := owl.New(GlobalOverlaid{})
+	assert.NoError(t, err)
+	assert.NotNil(t, resolver.Lookup("Token"))
+
+	resolver, err = owl.New(GlobalOverlaid{}, owl.WithOverlays(owl.NewOverlaySet()))
+	assert.NoError(t, err)
+	assert.Nil(t, resolver.Lookup("Token"))
+}
+
+func TestOverlaySet_LoadJSON(t *testing.T) {
+	assert := assert.New(t)
+	overlays := owl.NewOverlaySet()
+	err := overlays.LoadJSON(strings.NewReader(`[
+		{
+			"type": "github.com/ggicci/owl_test.ThirdPartyConfig",
+			"fields": {
+				"Auth.Token": "env=TOKEN",
+				"Debug": "-"
+			}
+		},
+		{
+			"type": "owl_test.ThirdPartyAuth",
+			"mode": "replace",
+			"fields": {
+				"Secret": "env=SECRET"
+			}
+		}
+	]`), ThirdPartyConfig{}, ThirdPartyAuth{})
+	assert.NoError(err)
+
+	resolver, err := owl.New(ThirdPartyConfig{}, owl.WithOverlays(overlays))
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{owl.NewDirective("env", "TOKEN")}, resolver.Lookup("Auth.Token").Directives)
+	assert.Equal([]*owl.Directive{owl.NewDirective("env", "SECRET")}, resolver.Lookup("Auth.Secret").Directives)
+
+	// Errors.
+	assert.ErrorContains(overlays.LoadJSON(strings.NewReader(`{`)), "decode overlays")
+	assert.ErrorIs(overlays.LoadJSON(strings.NewReader(`[{"type": "sdk.Unknown"}]`)), owl.ErrUnsupportedType)
+	assert.ErrorContains(overlays.LoadJSON(strings.NewReader(`[{"type": "owl_test.ThirdPartyAuth", "mode": "patch"}]`), ThirdPartyAuth{}), "unknown overlay mode")
+	assert.ErrorIs(overlays.Load(nil, 123), owl.ErrUnsupportedType)
+}
@@ -0,0 +1,143 @@
+package owl
+
+import (
+	"context"
+	"strings"
+)
+
+// Plan is what Resolve would do on a tree, i.e. the fields to visit and the
+// directives to run, in the order of execution. See Resolver.Plan.
+type Plan []*PlanStep
+
+// PlanStep is a field to visit in the plan.
+type PlanStep struct {
+	Path       string              // path of the field, see Resolver.PathString
+	Depth      int                 // depth of the field, the root is 0
+	Resolver   *Resolver           // the field resolver
+	Directives []*PlannedDirective // the directives to run, in the order of execution
+	Enter      bool                // whether the subtree would be entered
+	Dynamic    bool                // whether the value held by the interface field would be resolved, see WithDynamicTypesEnabled
+}
+
+// PlannedDirective is a directive to run in the plan.
+type PlannedDirective struct {
+	Directive *Directive        // a copy of the directive
+	Struct    bool              // whether it's a struct-level directive, see BlankField
+	PostOrder bool              // whether it runs after the children, see PostOrder
+	Executor  DirectiveExecutor // the executor found in the namespace, nil if missing
+}
+
+// Plan returns the execution plan of Resolve with the given options, without
+// running any directives. The fields are listed in depth-first order, along
+// with the directives to run on them in the order of execution, the executors
+// found in the effective namespace, and whether their subtrees would be
+// entered. The options work the same as in Resolve, e.g. WithNamespace,
+// WithNestedDirectivesEnabled and WithOnlyPaths. Since there's no value to
+// resolve, the dynamic types of the interface fields can't be planned. It is
+// useful for debugging and golden tests.
+func (r *Resolver) Plan(opts ...Option) (Plan, error) {
+	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
+	sel, err := r.selectPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	r.Walk(VisitorFuncs{
+		OnEnter: func(x *Resolver, depth int) (WalkAction, error) {
+			mode := sel.mode(x)
+			if mode == selectNone {
+				return WalkSkipChildren, nil
+			}
+
+			step := &PlanStep{
+				Path:     x.PathString(),
+				Depth:    depth,
+				Resolver: x,
+				Dynamic:  mode == selectAll && shouldResolveDynamicType(ctx, x),
+			}
+			step.Enter = !step.Dynamic && shouldResolveNestedDirectives(ctx, x)
+			plan = append(plan, step)
+			if mode == selectAll {
+				step.Directives = x.planDirectives(ctx, step.Enter)
+			}
+			if !step.Enter {
+				return WalkSkipChildren, nil
+			}
+			return WalkContinue, nil
+		},
+	})
+	return plan, nil
+}
+
+// planDirectives lists the directives to run on the field, in the same order
+// as Resolve runs them.
+func (r *Resolver) planDirectives(ctx context.Context, enter bool) []*PlannedDirective {
+	ns := r.namespaceIn(ctx)
+	var planned []*PlannedDirective
+	add := func(directives []*Directive, isStruct, postOrder bool) {
+		for _, d := range directives {
+			planned = append(planned, &PlannedDirective{
+				Directive: d.Copy(),
+				Struct:    isStruct,
+				PostOrder: postOrder,
+				Executor:  ns.LookupExecutor(d.Name),
+			})
+		}
+	}
+
+	add(r.directivesIn(ctx, r.Directives, phaseEnter), false, false)
+	if enter {
+		add(r.directivesIn(ctx, r.PreDirectives, phaseEnter), true, false)
+		add(r.directivesIn(ctx, r.PreDirectives, phaseLeave), true, true)
+		add(r.PostDirectives, true, true)
+	}
+	add(r.directivesIn(ctx, r.Directives, phaseLeave), false, true)
+	return planned
+}
+
+// String renders the plan as indented text, one field per line, followed by
+// its directives. The struct-level directives are marked with "(struct)", and
+// the ones whose executors can't be found are marked with "(missing
+// executor)". Ex:
+//
+//	(root)
+//	  Login: form=login [enter]
+//	    Login.Username: form=username; after:check
+func (p Plan) String() string {
+	var sb strings.Builder
+	for _, step := range p {
+		sb.WriteString(strings.Repeat("  ", step.Depth))
+		sb.WriteString(step.Resolver.displayPath())
+
+		var directives []string
+		for _, pd := range step.Directives {
+			directives = append(directives, pd.String())
+		}
+		if len(directives) > 0 {
+			sb.WriteString(": ")
+			sb.WriteString(strings.Join(directives, "; "))
+		}
+		switch {
+		case step.Dynamic:
+			sb.WriteString(" [dynamic]")
+		case step.Enter && !step.Resolver.IsRoot():
+			sb.WriteString(" [enter]")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (pd *PlannedDirective) String() string {
+	d := pd.Directive.Copy()
+	d.PostOrder = pd.PostOrder
+	s := d.String()
+	if pd.Struct {
+		s += " (struct)"
+	}
+	if pd.Executor == nil {
+		s += " (missing executor)"
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+package owl_test
+
+import (
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	ns.RegisterDirectiveExecutor("equal", owl.PostOrder(owl.DirectiveExecutorFunc(exeEqual)))
+
+	type Login struct {
+		_        struct{} `owl:"equal=Password,Confirm;default=login"`
+		Password string   `owl:"form=password"`
+		Confirm  string   `owl:"form=confirm;after:check"`
+	}
+	type Request struct {
+		Login      *Login     `owl:"form=login"`
+		Pagination Pagination `owl:"form=pagination"`
+		Shape      any        `owl:"form=shape"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	plan, err := resolver.Plan()
+	assert.NoError(err)
+	assert.Len(plan, 8)
+	assert.Equal("Login", plan[1].Path)
+	assert.Equal(1, plan[1].Depth)
+	assert.True(plan[1].Enter)
+	equal := plan[1].Directives[2]
+	assert.Equal(owl.NewDirective("equal", "Password", "Confirm"), equal.Directive)
+	assert.True(equal.Struct)
+	assert.True(equal.PostOrder) // requested by the executor
+	assert.NotNil(equal.Executor)
+	assert.Nil(plan[3].Directives[1].Executor)
+	assert.Equal(`(root)
+  Login: form=login; default=login (struct); after:equal=Password,Confirm (struct) [enter]
+    Login.Password: form=password
+    Login.Confirm: form=confirm; after:check (missing executor)
+  Pagination: form=pagination [enter]
+    Pagination.Page: form=page
+    Pagination.Size: form=size
+  Shape: form=shape
+`, plan.String())
+
+	// With options.
+	plan, err = resolver.Plan(owl.WithNestedDirectivesEnabled(false), owl.WithOnlyPaths("Login", "Shape"))
+	assert.NoError(err)
+	assert.Equal(`(root)
+  Login: form=login
+  Shape: form=shape
+`, plan.String())
+
+	plan, err = resolver.Plan(owl.WithDynamicTypesEnabled(true), owl.WithExcludedPaths("Login", "Pagination"))
+	assert.NoError(err)
+	assert.Equal(`(root)
+  Shape: form=shape [dynamic]
+`, plan.String())
+
+	plan, err = resolver.Plan(owl.WithOnlyPaths("Pagination.Page"), owl.WithNamespace(owl.NewNamespace()))
+	assert.NoError(err)
+	assert.Equal(`(root)
+  Pagination [enter]
+    Pagination.Page: form=page (missing executor)
+`, plan.String())
+
+	_, err = resolver.Plan(owl.WithOnlyPaths("["))
+	assert.Error(err)
+}
@@ -0,0 +1,78 @@
+package owl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Preload builds the resolver trees of the given struct types in parallel and
+// caches them in the global tree cache (see UseTreeCache), with the global
+// overlays (see RegisterOverlay) applied. It is meant to be
+// called during the initialization of an application. So the first call to
+// New for these types won't pay for building the trees, and the malformed
+// tags can be found at startup. All the errors will be returned together,
+// combined by errors.Join. Ex:
+//
+//	func init() {
+//	    if err := owl.Preload(LoginRequest{}, (*SearchRequest)(nil)); err != nil {
+//	        panic(err)
+//	    }
+//	}
+//
+// The types can be given in the same forms as New accepts, i.e. a struct
+// value, a pointer to a struct, or a reflect.Type. Use PreloadWith if the
+// trees are built by New with options.
+func Preload(types ...any) error {
+	return PreloadWith(nil, types...)
+}
+
+// PreloadWith works like Preload, but builds the trees with the given options,
+// the same as New does. So the trees are cached under the same keys as New
+// looks up. The options which affect the trees are WithTreeCache, WithOverlays
+// and WithDynamicTypesEnabled, while the others are ignored. Ex:
+//
+//	opts := []owl.Option{owl.WithTreeCache(cache), owl.WithDynamicTypesEnabled(true)}
+//	err := owl.PreloadWith(opts, LoginRequest{})
+//	...
+//	resolver, err := owl.New(LoginRequest{}, opts...) // hits the cache
+func PreloadWith(opts []Option, types ...any) error {
+	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
+	cache, overlays := treeCache(ctx), overlaySet(ctx)
+	dynamic, _ := ctx.Value(ckDynamicTypes).(bool)
+	errs := make([]error, len(types))
+
+	var wg sync.WaitGroup
+	for i, structValue := range types {
+		wg.Add(1)
+		go func(i int, structValue any) {
+			defer wg.Done()
+			typ, err := reflectStructType(structValue)
+			if err == nil {
+				_, err = buildAndCacheResolverTree(cache, overlays, typ, dynamic)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("preload %v failed: %w", describeType(structValue), err)
+			}
+		}(i, structValue)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// CachedTypes lists the struct types whose resolver trees are cached in the
+// global tree cache. It can be used in readiness checks, to tell whether the
+// expected types have been preloaded.
+func CachedTypes() []reflect.Type {
+	return defaultTreeCache.Types()
+}
+
+func describeType(structValue any) string {
+	if typ, ok := structValue.(reflect.Type); ok {
+		return typ.String()
+	}
+	return fmt.Sprintf("%T", structValue)
+}
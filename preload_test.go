@@ -0,0 +1,72 @@
+package owl_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreload(t *testing.T) {
+	defaultCache := owl.DefaultTreeCache()
+	defer owl.UseTreeCache(defaultCache)
+	cache := owl.NewLRUTreeCache(0)
+	owl.UseTreeCache(cache)
+
+	err := owl.Preload(Pagination{}, &User{}, reflect.TypeOf(UserSignUpForm{}))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []reflect.Type{
+		reflect.TypeOf(Pagination{}),
+		reflect.TypeOf(User{}),
+		reflect.TypeOf(UserSignUpForm{}),
+	}, owl.CachedTypes())
+
+	// New hits the preloaded trees.
+	_, err = owl.New(User{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+}
+
+func TestPreloadWith(t *testing.T) {
+	cache := owl.NewLRUTreeCache(0)
+	overlays := owl.NewOverlaySet()
+	opts := []owl.Option{
+		owl.WithTreeCache(cache),
+		owl.WithOverlays(overlays),
+		owl.WithDynamicTypesEnabled(true),
+	}
+
+	assert.NoError(t, owl.PreloadWith(opts, User{}))
+	assert.Equal(t, 1, cache.Stats().Size)
+
+	// New with the same options hits the preloaded tree.
+	_, err := owl.New(User{}, opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+	assert.Equal(t, uint64(1), cache.Stats().Misses)
+}
+
+func TestPreload_Errors(t *testing.T) {
+	defaultCache := owl.DefaultTreeCache()
+	defer owl.UseTreeCache(defaultCache)
+	owl.UseTreeCache(owl.NewLRUTreeCache(0))
+
+	type InvalidName struct {
+		Name string `owl:"invalid/name"`
+	}
+	type Duplicate struct {
+		Color string `owl:"form=red;form=blue"`
+	}
+
+	err := owl.Preload(Pagination{}, InvalidName{}, 123, Duplicate{}, nil)
+	assert.ErrorIs(t, err, owl.ErrInvalidDirectiveName)
+	assert.ErrorIs(t, err, owl.ErrDuplicateDirective)
+	assert.ErrorIs(t, err, owl.ErrUnsupportedType)
+	assert.ErrorContains(t, err, "preload owl_test.InvalidName failed")
+	assert.ErrorContains(t, err, "preload int failed")
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+
+	// The valid ones are still cached.
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(Pagination{})}, owl.CachedTypes())
+}
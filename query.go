@@ -0,0 +1,81 @@
+package owl
+
+import (
+	"path"
+	"strings"
+)
+
+// Select finds the field resolvers whose paths match the given pattern. The
+// pattern is a dotted path, relative to r, named by the naming strategy of the
+// tree (see WithNamingStrategy). The segments of the pattern can be:
+//
+//   - a field name, e.g. "Pagination.Page", which works like Lookup;
+//   - a glob pattern recognized by path.Match, e.g. "Items.*.Name", "User.Na*";
+//   - "**", which matches zero or more segments, e.g. "**.ID".
+//
+// The resolvers are returned in depth-first order, r itself is excluded. An
+// error will be returned if the pattern is malformed.
+func (r *Resolver) Select(pattern string) ([]*Resolver, error) {
+	var segments []string
+	if pattern != "" {
+		segments = strings.Split(pattern, ".")
+	}
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	depth := len(r.NamePath())
+	return r.Filter(func(x *Resolver) bool {
+		return x != r && matchPath(segments, x.NamePath()[depth:])
+	}), nil
+}
+
+// FindByDirective finds the field resolvers which have the directive of the
+// given name, e.g. FindByDirective("required"). The resolvers are returned in
+// depth-first order.
+func (r *Resolver) FindByDirective(name string) []*Resolver {
+	return r.Filter(func(x *Resolver) bool {
+		return x.GetDirective(name) != nil
+	})
+}
+
+// Filter finds the field resolvers which satisfy the given predicate. All the
+// resolvers in the tree, including r itself, will be visited in depth-first
+// order.
+func (r *Resolver) Filter(predicate func(*Resolver) bool) []*Resolver {
+	var found []*Resolver
+	r.Iterate(func(x *Resolver) error {
+		if predicate(x) {
+			found = append(found, x)
+		}
+		return nil
+	})
+	return found
+}
+
+// matchPath reports whether the field path matches the pattern segments. Only
+// well-formed patterns are expected here.
+func matchPath(pattern, fields []string) bool {
+	if len(pattern) == 0 {
+		return len(fields) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(fields); i++ {
+			if matchPath(pattern[1:], fields[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(fields) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], fields[0]); !ok {
+		return false
+	}
+	return matchPath(pattern[1:], fields[1:])
+}
@@ -0,0 +1,92 @@
+package owl_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+type OrderItem struct {
+	ID   string `owl:"form=id;required"`
+	Name string `owl:"form=name"`
+}
+
+type Order struct {
+	ID       string `owl:"form=id;required"`
+	Customer User
+	Items    struct {
+		First  OrderItem
+		Second OrderItem
+	}
+}
+
+func pathsOf(resolvers []*owl.Resolver) []string {
+	paths := make([]string, len(resolvers))
+	for i, r := range resolvers {
+		paths[i] = r.PathString()
+	}
+	return paths
+}
+
+func TestResolver_Select(t *testing.T) {
+	resolver, err := owl.New(Order{})
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"ID", []string{"ID"}},
+		{"Customer.Name", []string{"Customer.Name"}},
+		{"Items.*.Name", []string{"Items.First.Name", "Items.Second.Name"}},
+		{"**.ID", []string{"ID", "Items.First.ID", "Items.Second.ID"}},
+		{"Customer.*", []string{"Customer.Name", "Customer.Gender", "Customer.Birthday"}},
+		{"Customer.**", []string{"Customer", "Customer.Name", "Customer.Gender", "Customer.Birthday"}},
+		{"Customer.B*", []string{"Customer.Birthday"}},
+		{"Items.*", []string{"Items.First", "Items.Second"}},
+		{"NotExist", []string{}},
+		{"", []string{}},
+	}
+
+	for _, testcase := range testcases {
+		found, err := resolver.Select(testcase.pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, testcase.expected, pathsOf(found), "pattern: %q", testcase.pattern)
+	}
+
+	// Relative to the current resolver.
+	found, err := resolver.Lookup("Items").Select("*.ID")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Items.First.ID", "Items.Second.ID"}, pathsOf(found))
+
+	_, err = resolver.Select("Items.[")
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestResolver_FindByDirective(t *testing.T) {
+	resolver, err := owl.New(Order{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"ID", "Items.First.ID", "Items.Second.ID"}, pathsOf(resolver.FindByDirective("required")))
+	assert.Equal(t, []string{"Customer.Gender"}, pathsOf(resolver.FindByDirective("default")))
+	assert.Empty(t, resolver.FindByDirective("header"))
+}
+
+func TestResolver_Filter(t *testing.T) {
+	resolver, err := owl.New(Order{})
+	assert.NoError(t, err)
+
+	leaves := resolver.Lookup("Items").Filter((*owl.Resolver).IsLeaf)
+	assert.Equal(t, []string{
+		"Items.First.ID",
+		"Items.First.Name",
+		"Items.Second.ID",
+		"Items.Second.Name",
+	}, pathsOf(leaves))
+
+	all := resolver.Filter(func(*owl.Resolver) bool { return true })
+	assert.Same(t, resolver, all[0])
+	assert.Len(t, all, 13)
+}
@@ -5,15 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Saves all the built resolver trees without applying options.
-// The key is the struct type.
-var builtTrees sync.Map
-
 // Resolver is a field resolver. Which is a node in the resolver tree.
 // The resolver tree is built from a struct value. Each node represents a
 // field in the struct. The root node represents the struct itself.
@@ -27,41 +25,89 @@ type Resolver struct {
 	Parent     *Resolver
 	Children   []*Resolver
 	Context    context.Context // save custom resolver settings here
+
+	// PreDirectives and PostDirectives are the struct-level directives, which
+	// run on the struct value itself, before and after its children. They are
+	// declared by the blank fields of the struct, see BlankField.
+	PreDirectives  []*Directive
+	PostDirectives []*Directive
+
+	frozen int32 // accessed atomically, see Freeze
 }
 
 // New builds a resolver tree from a struct value. The given options will be
 // applied to all the resolvers. In the resolver tree, each node is also a
-// Resolver. Available options are WithNamespace, WithNestedDirectivesEnabled
-// and WithValue.
+// Resolver. Available options are WithNamespace, WithNestedDirectivesEnabled,
+// WithLazyAllocation, WithFailFast, WithMaxErrors, WithNamingStrategy,
+// WithTreeCache, WithExcludedFields, WithOverlays and WithValue.
 func New(structValue interface{}, opts ...Option) (*Resolver, error) {
 	typ, err := reflectStructType(structValue)
 	if err != nil {
 		return nil, err
 	}
 
-	tree, err := buildAndCacheResolverTree(typ)
+	// Apply options, build the context for each resolver.
+	defaultOpts := []Option{WithNamespace(defaultNS)}
+	opts = append(defaultOpts, opts...)
+	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
+
+	dynamic, _ := ctx.Value(ckDynamicTypes).(bool)
+	tree, err := buildAndCacheResolverTree(treeCache(ctx), overlaySet(ctx), typ, dynamic)
 	if err != nil {
 		return nil, err
 	}
 	tree = tree.Copy()
-
-	// Apply options, build the context for each resolver.
-	defaultOpts := []Option{WithNamespace(defaultNS)}
-	opts = append(defaultOpts, opts...)
-	tree.applyContext(buildContextWithOptionsApplied(context.Background(), opts...))
+	tree.applyContext(ctx)
 
 	if tree.Namespace() == nil {
 		return nil, errors.New("nil namespace")
 	}
 
+	if patterns, ok := ctx.Value(ckExcludedFields).([]string); ok {
+		if err := tree.excludeFields(patterns); err != nil {
+			return nil, err
+		}
+	}
+
 	return tree, nil
 }
 
+// excludeFields removes the fields matching the patterns (see Select) from the
+// tree, along with their subtrees.
+func (r *Resolver) excludeFields(patterns []string) error {
+	for _, pattern := range patterns {
+		found, err := r.Select(pattern)
+		if err != nil {
+			return fmt.Errorf("exclude fields %q failed: %w", pattern, err)
+		}
+		for _, field := range found {
+			field.Parent.removeChild(field)
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) removeChild(child *Resolver) {
+	for i, x := range r.Children {
+		if x == child {
+			r.Children = append(r.Children[:i:i], r.Children[i+1:]...)
+			return
+		}
+	}
+}
+
 // Copy returns a copy of the resolver tree. The copy is a deep copy, which
-// means the children are also copied.
+// means the children are also copied. The copy is always mutable, even if the
+// original tree is frozen.
 func (r *Resolver) Copy() *Resolver {
-	resolverCopy := new(Resolver)
-	*resolverCopy = *r
+	// Copy the fields one by one, rather than the whole struct, since frozen
+	// can be written by Freeze concurrently. The copy is mutable.
+	resolverCopy := &Resolver{
+		Type:    r.Type,
+		Field:   r.Field,
+		Parent:  r.Parent,
+		Context: r.Context,
+	}
 
 	// Copy index and path.
 	resolverCopy.Index = make([]int, len(r.Index))
@@ -74,6 +120,8 @@ func (r *Resolver) Copy() *Resolver {
 	for i, d := range r.Directives {
 		resolverCopy.Directives[i] = d.Copy()
 	}
+	resolverCopy.PreDirectives = copyDirectives(r.PreDirectives)
+	resolverCopy.PostDirectives = copyDirectives(r.PostDirectives)
 
 	// Copy the children and set the parent.
 	resolverCopy.Children = make([]*Resolver, len(r.Children))
@@ -84,6 +132,17 @@ func (r *Resolver) Copy() *Resolver {
 	return resolverCopy
 }
 
+func copyDirectives(directives []*Directive) []*Directive {
+	if directives == nil {
+		return nil
+	}
+	copied := make([]*Directive, len(directives))
+	for i, d := range directives {
+		copied[i] = d.Copy()
+	}
+	return copied
+}
+
 func (r *Resolver) IsRoot() bool {
 	return r.Parent == nil
 }
@@ -92,8 +151,20 @@ func (r *Resolver) IsLeaf() bool {
 	return len(r.Children) == 0
 }
 
+// PathString returns the dotted path of the resolver, named by the naming
+// strategy of the tree. e.g. "Pagination.Page", or "pagination.page" when
+// WithNamingStrategy(JSONFieldName) was applied.
 func (r *Resolver) PathString() string {
-	return strings.Join(r.Path, ".")
+	return strings.Join(r.NamePath(), ".")
+}
+
+// displayPath returns the path to show in the messages, "(root)" for the root,
+// whose path is empty.
+func (r *Resolver) displayPath() string {
+	if r.IsRoot() {
+		return "(root)"
+	}
+	return r.PathString()
 }
 
 func (r *Resolver) GetDirective(name string) *Directive {
@@ -105,13 +176,133 @@ func (r *Resolver) GetDirective(name string) *Directive {
 	return nil
 }
 
+// RemoveDirective removes the directive of the given name and returns it.
+// Returns nil if the directive was not found, or the tree is frozen. Use
+// DeleteDirective to tell the two cases apart.
 func (r *Resolver) RemoveDirective(name string) *Directive {
+	d, _ := r.DeleteDirective(name)
+	return d
+}
+
+// DeleteDirective works like RemoveDirective, but fails with ErrFrozenTree if
+// the tree is frozen, or ErrDirectiveNotFound if the directive was not found.
+func (r *Resolver) DeleteDirective(name string) (*Directive, error) {
+	if err := r.checkMutable(); err != nil {
+		return nil, err
+	}
 	for i, d := range r.Directives {
 		if d.Name == name {
 			r.Directives = append(r.Directives[:i], r.Directives[i+1:]...)
-			return d
+			return d, nil
 		}
 	}
+	return nil, fmt.Errorf("%w: %q", ErrDirectiveNotFound, name)
+}
+
+// AddDirective appends a directive to the resolver.
+func (r *Resolver) AddDirective(d *Directive) error {
+	return r.InsertDirective(len(r.Directives), d)
+}
+
+// InsertDirective inserts a directive to the resolver at index i, which
+// should be in the range of [0, len(r.Directives)].
+func (r *Resolver) InsertDirective(i int, d *Directive) error {
+	if err := r.checkMutable(); err != nil {
+		return err
+	}
+	if i < 0 || i > len(r.Directives) {
+		return fmt.Errorf("index %d out of range [0, %d]", i, len(r.Directives))
+	}
+	if err := validateDirectives(append([]*Directive{d}, r.Directives...)); err != nil {
+		return err
+	}
+
+	directives := make([]*Directive, 0, len(r.Directives)+1)
+	directives = append(directives, r.Directives[:i]...)
+	directives = append(directives, d)
+	r.Directives = append(directives, r.Directives[i:]...)
+	return nil
+}
+
+// ReplaceDirective replaces the directive of the given name with d in place.
+func (r *Resolver) ReplaceDirective(name string, d *Directive) error {
+	if err := r.checkMutable(); err != nil {
+		return err
+	}
+	for i, old := range r.Directives {
+		if old.Name != name {
+			continue
+		}
+		directives := make([]*Directive, len(r.Directives))
+		copy(directives, r.Directives)
+		directives[i] = d
+		if err := validateDirectives(directives); err != nil {
+			return err
+		}
+		r.Directives = directives
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrDirectiveNotFound, name)
+}
+
+// SetDirectives replaces all the directives of the resolver.
+func (r *Resolver) SetDirectives(directives ...*Directive) error {
+	if err := r.checkMutable(); err != nil {
+		return err
+	}
+	if err := validateDirectives(directives); err != nil {
+		return err
+	}
+	r.Directives = append([]*Directive{}, directives...)
+	return nil
+}
+
+// Freeze makes the whole tree, which r belongs to, immutable. The mutators,
+// e.g. AddDirective, will fail with ErrFrozenTree afterwards. It is called
+// implicitly on the first call to Resolve, ResolveTo or Scan. Use Copy to get
+// a mutable copy of a frozen tree.
+func (r *Resolver) Freeze() {
+	root := r
+	for root.Parent != nil {
+		root = root.Parent
+	}
+	if root.IsFrozen() {
+		return
+	}
+	root.Iterate(func(x *Resolver) error {
+		atomic.StoreInt32(&x.frozen, 1)
+		return nil
+	})
+}
+
+// IsFrozen reports whether the tree is frozen. See Freeze.
+func (r *Resolver) IsFrozen() bool {
+	return atomic.LoadInt32(&r.frozen) == 1
+}
+
+func (r *Resolver) checkMutable() error {
+	if r.IsFrozen() {
+		return fmt.Errorf("%w: cannot modify %q", ErrFrozenTree, r.String())
+	}
+	return nil
+}
+
+// validateDirectives validates the directives to be set to a resolver. Which
+// follows the same rules as ParseTag.
+func validateDirectives(directives []*Directive) error {
+	existed := make(map[string]bool)
+	for _, d := range directives {
+		if d == nil {
+			return errors.New("nil directive")
+		}
+		if !isValidDirectiveName(d.Name) {
+			return invalidDirectiveName(d.Name)
+		}
+		if existed[d.Name] {
+			return duplicateDirective(d.Name)
+		}
+		existed[d.Name] = true
+	}
 	return nil
 }
 
@@ -119,7 +310,9 @@ func (r *Resolver) Namespace() *Namespace {
 	return r.Context.Value(ckNamespace).(*Namespace)
 }
 
-// Find finds a field resolver by path. e.g. "Pagination.Page", "User.Name", etc.
+// Lookup finds a field resolver by path. e.g. "Pagination.Page", "User.Name",
+// etc. The path is named by the naming strategy of the tree, see
+// WithNamingStrategy.
 func (r *Resolver) Lookup(path string) *Resolver {
 	var paths []string
 	if path != "" {
@@ -134,7 +327,7 @@ func findResolver(root *Resolver, path []string) *Resolver {
 	}
 
 	for _, field := range root.Children {
-		if field.Field.Name == path[0] {
+		if field.Name() == path[0] {
 			return findResolver(field, path[1:])
 		}
 	}
@@ -142,25 +335,136 @@ func findResolver(root *Resolver, path []string) *Resolver {
 	return nil
 }
 
+// LookupByIndex finds a field resolver by the reflect index path relative to
+// r, see reflect.Value.FieldByIndex. e.g. []int{3, 1} for "Pagination.Size"
+// if Pagination is the 4th field and Size is the 2nd field of Pagination.
+func (r *Resolver) LookupByIndex(index []int) *Resolver {
+	if len(index) == 0 {
+		return r
+	}
+
+	for _, field := range r.Children {
+		if field.Index[len(field.Index)-1] == index[0] {
+			return field.LookupByIndex(index[1:])
+		}
+	}
+
+	return nil
+}
+
+// LookupByPointer finds a field resolver by the address of a field. The root
+// is a pointer to the value of the type r holds, and fieldPtr is a pointer to
+// one of its fields. Ex:
+//
+//	resolver.LookupByPointer(req, &req.Page.Size) // => "Page.Size"
+//
+// Returns nil if the field was not found in the tree, or the given values
+// mismatch the tree.
+func (r *Resolver) LookupByPointer(root any, fieldPtr any) *Resolver {
+	rv := reflect.ValueOf(root)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Type() != r.Type {
+		return nil
+	}
+	fp := reflect.ValueOf(fieldPtr)
+	if fp.Kind() != reflect.Pointer || fp.IsNil() {
+		return nil
+	}
+	return findResolverByPointer(r, rv.Elem(), fp)
+}
+
+// findResolverByPointer finds the resolver whose field address is fp. NOTE: v
+// is the addressable value which the resolver r represents.
+func findResolverByPointer(r *Resolver, v reflect.Value, fp reflect.Value) *Resolver {
+	// The first field of a struct shares the address with the struct, so the
+	// type is also compared.
+	if v.Addr().Pointer() == fp.Pointer() && v.Type() == fp.Type().Elem() {
+		return r
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, field := range r.Children {
+		if found := findResolverByPointer(field, v.Field(field.Index[len(field.Index)-1]), fp); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
 func shouldResolveNestedDirectives(ctx context.Context, r *Resolver) bool {
 	if r.IsRoot() {
 		return true // always resolve the root
 	}
-	if r.IsLeaf() {
+	if r.IsLeaf() && !r.hasStructDirectives() {
 		return false // leaves have no children
 	}
+	return isNestedDirectivesEnabled(ctx, r)
+}
+
+func (r *Resolver) hasStructDirectives() bool {
+	return len(r.PreDirectives) > 0 || len(r.PostDirectives) > 0
+}
+
+func isNestedDirectivesEnabled(ctx context.Context, r *Resolver) bool {
 	if len(r.Directives) == 0 {
 		return true // go deeper if no directives on current field
 	}
-	if ctx != nil && ctx.Value(ckResolveNestedDirectives) != nil {
-		return ctx.Value(ckResolveNestedDirectives).(bool)
-	}
-	if r.Context.Value(ckResolveNestedDirectives) != nil {
-		return r.Context.Value(ckResolveNestedDirectives).(bool)
+	if enabled, ok := lookupOption(ctx, r, ckResolveNestedDirectives).(bool); ok {
+		return enabled
 	}
 	return true
 }
 
+// isFailFastEnabled reports whether to stop at the first error, the default
+// value differs between Resolve and Scan.
+func isFailFastEnabled(ctx context.Context, r *Resolver, defaultValue bool) bool {
+	if failFast, ok := lookupOption(ctx, r, ckFailFast).(bool); ok {
+		return failFast
+	}
+	return defaultValue
+}
+
+// maxErrors returns the cap of the collected errors, 0 means no cap.
+func maxErrors(ctx context.Context, r *Resolver) int {
+	n, _ := lookupOption(ctx, r, ckMaxErrors).(int)
+	return n
+}
+
+func isTransactionEnabled(ctx context.Context, r *Resolver) bool {
+	enabled, _ := lookupOption(ctx, r, ckTransaction).(bool)
+	return enabled
+}
+
+func isPanicRecoveryEnabled(ctx context.Context, r *Resolver) bool {
+	enabled, _ := lookupOption(ctx, r, ckPanicRecovery).(bool)
+	return enabled
+}
+
+func isLazyAllocationEnabled(ctx context.Context, r *Resolver) bool {
+	lazy, _ := lookupOption(ctx, r, ckLazyAllocation).(bool)
+	return lazy
+}
+
+// lookupOption returns the value of an option bound to the given key. The value
+// set in Resolve() or Scan(), i.e. in ctx, takes precedence over the value set
+// in New(), i.e. in r.Context. Returns nil if the option was not set at all.
+func lookupOption(ctx context.Context, r *Resolver, key contextKey) interface{} {
+	if ctx != nil {
+		if value := ctx.Value(key); value != nil {
+			return value
+		}
+	}
+	return r.Context.Value(key)
+}
+
 func (r *Resolver) String() string {
 	return fmt.Sprintf("%s (%v)", r.PathString(), r.Type)
 }
@@ -210,8 +514,18 @@ func (r *Resolver) applyContext(ctx context.Context) {
 // value, try to access each corresponding field. Even scan fails on one of the fields,
 // it will continue to scan the rest of the fields. The returned error can be a
 // multi-error combined by errors.Join, which contains all the errors that occurred
-// during the scan.
+// during the scan. Use WithFailFast(true) to stop at the first error, or
+// WithMaxErrors to cap the number of the errors.
 func (r *Resolver) Scan(value any, opts ...Option) error {
+	return r.ScanContext(context.Background(), value, opts...)
+}
+
+// ScanContext works like Scan, but the directives run with the given context,
+// i.e. DirectiveRuntime.Context is derived from ctx. Once ctx is done, the scan
+// stops before running the next directive, and returns an error wrapping
+// ctx.Err().
+func (r *Resolver) ScanContext(ctx context.Context, value any, opts ...Option) error {
+	r.Freeze()
 	if value == nil {
 		return fmt.Errorf("cannot scan nil value")
 	}
@@ -225,25 +539,155 @@ func (r *Resolver) Scan(value any, opts ...Option) error {
 			ErrTypeMismatch, rv.Type(), r.Type)
 	}
 
-	var errs []error
-	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
-	r.iterate(ctx, func(r *Resolver) error {
-		errs = append(errs, scan(r, ctx, rv))
-		return nil
-	})
+	ctx = buildContextWithOptionsApplied(ctx, opts...)
+	sel, err := r.selectPaths(ctx)
+	if err != nil {
+		return err
+	}
+	return ignoreStop(r.scan(ctx, rv, sel))
+}
+
+// scan scans the given value against the tree and joins all the errors. Only
+// the selected fields are scanned, nil means all.
+func (r *Resolver) scan(ctx context.Context, rootValue reflect.Value, sel *pathSelection) error {
+	v := &scanVisitor{
+		ctx:       ctx,
+		rootValue: rootValue,
+		sel:       sel,
+		failFast:  isFailFastEnabled(ctx, r, false),
+		maxErrors: maxErrors(ctx, r),
+	}
+	walker, done := r.observe(ctx, OpScan, v)
+	r.Walk(walker)
+	err := errors.Join(v.errs...)
+	if err == nil && v.stopped {
+		err = ErrStop // let the outer scan (of the dynamic types) stop as well
+	}
+	done(err)
+	return err
+}
 
-	return errors.Join(errs...)
+// scanVisitor runs the directives on the fields of a struct value. It keeps
+// going on errors, and collects them, unless fail-fast, see WithFailFast.
+type scanVisitor struct {
+	ctx       context.Context
+	rootValue reflect.Value
+	sel       *pathSelection
+	failFast  bool
+	maxErrors int
+	errs      []error
+	stopped   bool         // whether stopped by ErrStop
+	frames    []*scanFrame // the fields being visited
 }
 
-func scan(resolver *Resolver, ctx context.Context, rootValue reflect.Value) error {
+type scanFrame struct {
+	fv      reflect.Value   // the field value, invalid if failed to scan the field
+	ctx     context.Context // the context passed along the directives of the field
+	entered bool            // whether the struct (of the field) was entered
+}
+
+func (v *scanVisitor) Enter(x *Resolver, depth int) (WalkAction, error) {
+	if err := v.ctx.Err(); err != nil {
+		if !errors.Is(errors.Join(v.errs...), err) { // not reported yet
+			v.errs = append(v.errs, fmt.Errorf("stopped before scanning %q: %w", x.displayPath(), err))
+		}
+		return WalkStop, nil
+	}
+
+	frame := &scanFrame{ctx: v.ctx}
+	v.frames = append(v.frames, frame)
+	mode := v.sel.mode(x)
+	if mode == selectNone {
+		return WalkSkipChildren, nil
+	}
+	if mode == selectAll {
+		fl, err := v.scanField(x, frame)
+		if v.collect(err) {
+			return WalkStop, nil
+		}
+		if fl != flowContinue {
+			return v.next(fl)
+		}
+	}
+
+	if !shouldResolveNestedDirectives(v.ctx, x) {
+		return WalkSkipChildren, nil
+	}
+	if mode == selectPass {
+		return WalkContinue, nil // only the selected children are scanned
+	}
+	frame.entered = true
+	fl, err := v.scanStruct(x, phaseEnter)
+	if v.collect(err) {
+		return WalkStop, nil
+	}
+	return v.next(fl)
+}
+
+func (v *scanVisitor) Leave(x *Resolver, depth int) (WalkAction, error) {
+	frame := v.frames[len(v.frames)-1]
+	v.frames = v.frames[:len(v.frames)-1]
+
+	if frame.entered {
+		fl, err := v.scanStruct(x, phaseLeave)
+		if v.collect(err) {
+			return WalkStop, nil
+		}
+		if fl == flowStop {
+			return v.next(fl)
+		}
+	}
+	if frame.fv.IsValid() {
+		_, fl, _, err := x.runDirectives(frame.ctx, frame.fv, phaseLeave)
+		if err != nil {
+			err = &ScanError{
+				fieldError: fieldError{
+					Err:      err,
+					Resolver: x,
+				},
+			}
+		}
+		if v.collect(err) {
+			return WalkStop, nil
+		}
+		return v.next(fl)
+	}
+	return WalkContinue, nil
+}
+
+// next returns the walk action of the control flow requested by the executors.
+func (v *scanVisitor) next(fl flow) (WalkAction, error) {
+	switch fl {
+	case flowStop:
+		v.stopped = true
+		return WalkStop, nil
+	case flowSkipChildren:
+		return WalkSkipChildren, nil
+	}
+	return WalkContinue, nil
+}
+
+// collect collects the error, and reports whether to stop scanning, see
+// WithFailFast and WithMaxErrors.
+func (v *scanVisitor) collect(err error) (stop bool) {
+	if err == nil {
+		return false
+	}
+	v.errs = append(v.errs, err)
+	return v.failFast || (v.maxErrors > 0 && len(v.errs) >= v.maxErrors)
+}
+
+// scanField runs the pre-order directives on the field, and scans the value
+// held by the interface field.
+func (v *scanVisitor) scanField(resolver *Resolver, frame *scanFrame) (flow, error) {
 	if resolver.IsRoot() {
-		return nil // skip on root, which is the root struct itself
+		return flowContinue, nil // skip on root, which is the root struct itself
 	}
 
 	// Get the field value this resolver points to.
-	fv, err := rootValue.FieldByIndexErr(resolver.Index)
+	fv, err := v.rootValue.FieldByIndexErr(resolver.Index)
 	if err != nil {
-		return &ScanError{
+		return flowContinue, &ScanError{
 			fieldError: fieldError{
 				Err:      fmt.Errorf("%w: %v", ErrScanNilField, err),
 				Resolver: resolver,
@@ -252,22 +696,66 @@ func scan(resolver *Resolver, ctx context.Context, rootValue reflect.Value) erro
 	}
 
 	// Run directives on the field.
-	if err := resolver.runDirectives(ctx, fv); err != nil {
-		return &ScanError{
+	var fl flow
+	if frame.ctx, fl, _, err = resolver.runDirectives(v.ctx, fv, phaseEnter); err != nil {
+		return fl, &ScanError{
 			fieldError: fieldError{
 				Err:      err,
 				Resolver: resolver,
 			},
 		}
 	}
+	frame.fv = fv
+	if fl != flowContinue {
+		return fl, nil
+	}
 
-	return nil
+	// Scan the value held by the interface field.
+	if shouldResolveDynamicType(v.ctx, resolver) {
+		if err := resolver.scanDynamicType(v.ctx, fv); err != ErrStop {
+			return flowContinue, err
+		}
+		return flowStop, nil
+	}
+	return flowContinue, nil
+}
+
+// scanStruct runs the struct-level directives on the struct value the resolver
+// points to. Nil pointers are skipped, which are reported by their children.
+func (v *scanVisitor) scanStruct(resolver *Resolver, p phase) (flow, error) {
+	if !resolver.hasStructDirectives() {
+		return flowContinue, nil
+	}
+
+	sv := v.rootValue
+	if !resolver.IsRoot() {
+		fv, err := v.rootValue.FieldByIndexErr(resolver.Index)
+		if err != nil {
+			return flowContinue, nil
+		}
+		sv = reflect.Indirect(fv)
+	}
+	if !sv.IsValid() {
+		return flowContinue, nil
+	}
+
+	fl, err := resolver.runStructDirectives(v.ctx, sv, p)
+	if err != nil {
+		return fl, &ScanError{
+			fieldError: fieldError{
+				Err:      err,
+				Resolver: resolver,
+			},
+		}
+	}
+	return fl, nil
 }
 
 // Resolve resolves the struct type by traversing the tree in depth-first order.
 // Typically it is used to create a new struct instance by reading from some
 // data source. This method always creates a new value of the type the resolver
-// holds. And runs the directives on each field.
+// holds. And runs the directives on each field, before resolving its children
+// by default, or after that if requested, see PostOrder.
 //
 // Use WithValue to create an Option that can add custom values to the context,
 // the context can be used by the directive executors during the resolution.
@@ -280,68 +768,452 @@ func scan(resolver *Resolver, ctx context.Context, rootValue reflect.Value) erro
 //	settings, err := resolver.Resolve(WithValue("app_config", appConfig))
 //
 // NOTE: while iterating the tree, if resolving a field failed, the iteration
-// will be stopped immediately and the error will be returned.
+// will be stopped immediately and the error will be returned. Use
+// WithFailFast(false) to collect the errors of all the failed fields instead.
 func (r *Resolver) Resolve(opts ...Option) (reflect.Value, error) {
-	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
+	return r.ResolveContext(context.Background(), opts...)
+}
+
+// ResolveContext works like Resolve, but the directives run with the given
+// context, i.e. DirectiveRuntime.Context is derived from ctx, which makes the
+// deadline and cancellation of a request available to the executors. Once ctx
+// is done, the resolution stops before running the next directive, and returns
+// an error wrapping ctx.Err().
+func (r *Resolver) ResolveContext(ctx context.Context, opts ...Option) (reflect.Value, error) {
+	r.Freeze()
+	ctx = buildContextWithOptionsApplied(ctx, opts...)
 	rootValue := reflect.New(r.Type) // Type:User -> rootValue:*User
-	return rootValue, r.resolve(ctx, rootValue)
+	sel, err := r.selectPaths(ctx)
+	if err != nil {
+		return rootValue, err
+	}
+	_, err = r.resolve(ctx, rootValue, sel)
+	return rootValue, ignoreStop(err)
 }
 
 // ResolveTo works like Resolve, but it resolves the struct value to the given
 // pointer value instead of creating a new value. The pointer value must be
-// non-nil and a pointer to the type the resolver holds.
+// non-nil and a pointer to the type the resolver holds. Use WithTransaction to
+// leave the value untouched when the resolution fails.
 func (r *Resolver) ResolveTo(value any, opts ...Option) (err error) {
+	return r.ResolveToContext(context.Background(), value, opts...)
+}
+
+// ResolveToContext works like ResolveTo, with the given context, see
+// ResolveContext.
+func (r *Resolver) ResolveToContext(ctx context.Context, value any, opts ...Option) (err error) {
+	r.Freeze()
 	rv, err := reflectResolveTargetValue(value, r.Type)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrInvalidResolveTarget, err)
 	}
-	ctx := buildContextWithOptionsApplied(context.Background(), opts...)
-	return r.resolve(ctx, rv.Addr())
+	ctx = buildContextWithOptionsApplied(ctx, opts...)
+	sel, err := r.selectPaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := rv.Addr()
+	transactional := isTransactionEnabled(ctx, r)
+	if transactional {
+		target = reflect.New(r.Type)
+		target.Elem().Set(rv)
+		if err := r.cloneTree(ctx, target.Elem()); err != nil {
+			return err
+		}
+	}
+	_, err = r.resolve(ctx, target, sel)
+	err = ignoreStop(err)
+	if transactional && err == nil {
+		rv.Set(target.Elem()) // commit
+	}
+	return err
+}
+
+// cloneTree copies the nested structs referred by the pointer fields of the
+// struct value sv along the tree, so that resolving into sv won't touch the
+// original ones. The values held by the interface fields are copied along the
+// subtrees of their dynamic types, if they are going to be resolved.
+func (r *Resolver) cloneTree(ctx context.Context, sv reflect.Value) error {
+	for _, child := range r.Children {
+		fv := sv.Field(child.Index[len(child.Index)-1])
+		switch fv.Kind() {
+		case reflect.Ptr:
+			if fv.IsNil() {
+				continue
+			}
+			fv.Set(clonePointer(fv))
+			if fv.Elem().Kind() == reflect.Struct {
+				if err := child.cloneTree(ctx, fv.Elem()); err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			if err := child.cloneTree(ctx, fv); err != nil {
+				return err
+			}
+		case reflect.Interface:
+			if err := child.cloneDynamic(ctx, fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cloneDynamic copies the value held by the interface field fv, see cloneTree.
+func (r *Resolver) cloneDynamic(ctx context.Context, fv reflect.Value) error {
+	if fv.IsNil() {
+		return nil
+	}
+
+	// Get the copy of the dynamic value, which must be addressable.
+	dynamic := fv.Elem()
+	var cp reflect.Value
+	switch dynamic.Kind() {
+	case reflect.Ptr:
+		if dynamic.IsNil() {
+			return nil
+		}
+		cp = clonePointer(dynamic)
+	case reflect.Struct:
+		cp = reflect.New(dynamic.Type())
+		cp.Elem().Set(dynamic)
+	default:
+		return nil // not a reference to any struct
+	}
+
+	if cp.Elem().Kind() == reflect.Struct && shouldResolveDynamicType(ctx, r) {
+		subtree, err := r.dynamicSubtree(cp.Elem().Type())
+		if err != nil {
+			return err
+		}
+		if err := subtree.cloneTree(ctx, cp.Elem()); err != nil {
+			return err
+		}
+	}
+	if dynamic.Kind() == reflect.Ptr {
+		fv.Set(cp)
+	} else {
+		fv.Set(cp.Elem())
+	}
+	return nil
+}
+
+// clonePointer returns a pointer to a copy of the value p points to.
+func clonePointer(p reflect.Value) reflect.Value {
+	cp := reflect.New(p.Type().Elem())
+	cp.Elem().Set(p.Elem())
+	return cp
 }
 
 // resolve runs the directives on the current field and resolves the children fields.
+// Only the selected fields are resolved, nil means all. Reports whether any
+// directive set a value, see WithLazyAllocation.
 // NOTE: rootValue must be a pointer to a type, i.e. *User, not User.
-func (root *Resolver) resolve(ctx context.Context, rootValue reflect.Value) error {
+func (root *Resolver) resolve(ctx context.Context, rootValue reflect.Value, sel *pathSelection) (bool, error) {
+	v := &resolveVisitor{
+		ctx:       ctx,
+		start:     root,
+		rootValue: rootValue,
+		sel:       sel,
+		failFast:  isFailFastEnabled(ctx, root, true),
+		maxErrors: maxErrors(ctx, root),
+	}
+	walker, done := root.observe(ctx, OpResolve, v)
+	err := root.Walk(walker)
+	if !v.failFast {
+		err = errors.Join(append(v.errs, err)...)
+	}
+	if err == nil && v.stopped {
+		err = ErrStop // let the outer resolution (of the dynamic types) stop as well
+	}
+	done(err)
+	return v.set, err
+}
+
+// ignoreStop hides ErrStop from the callers of Resolve and Scan.
+func ignoreStop(err error) error {
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// resolveVisitor resolves the fields of a struct value. It stops on the first
+// error, or collects the errors if not fail-fast, see WithFailFast.
+type resolveVisitor struct {
+	ctx       context.Context
+	start     *Resolver
+	rootValue reflect.Value
+	sel       *pathSelection
+	failFast  bool
+	maxErrors int
+	errs      []error
+	stopped   bool            // whether stopped by ErrStop
+	set       bool            // whether any directive set a value, see WithLazyAllocation
+	frames    []*resolveFrame // the fields being visited
+}
+
+type resolveFrame struct {
+	rv         reflect.Value   // pointer to the field value
+	underlying reflect.Value   // pointer to the struct value, valid if the struct was entered
+	ctx        context.Context // the context passed along the directives of the field
+	allocated  bool            // whether the pointer was instantiated by us
+	failed     bool            // whether the field failed, see WithFailFast
+	mode       selectMode      // how the field is visited, see WithOnlyPaths
+	set        bool            // whether the directives of the field set a value
+	nestedSet  bool            // whether any directive of the descendants set a value
+}
+
+func (v *resolveVisitor) Enter(x *Resolver, depth int) (WalkAction, error) {
+	if err := v.ctx.Err(); err != nil {
+		return WalkStop, v.fail(x, fmt.Errorf("stopped before resolving %q: %w", x.displayPath(), err))
+	}
+
+	rv := v.rootValue
+	if len(v.frames) > 0 {
+		parent := v.frames[len(v.frames)-1]
+		rv = parent.underlying.Elem().Field(x.Index[len(x.Index)-1]).Addr()
+	}
+	frame := &resolveFrame{rv: rv, ctx: v.ctx, mode: v.sel.mode(x)}
+	v.frames = append(v.frames, frame)
+	if frame.mode == selectNone {
+		return WalkSkipChildren, nil
+	}
+
 	// Run the directives on current field.
-	if err := root.runDirectives(ctx, rootValue); err != nil {
-		return err
+	var fl flow
+	var err error
+	if frame.mode == selectAll {
+		if frame.ctx, fl, err = v.runDirectives(x, frame, v.ctx, phaseEnter); err != nil {
+			return v.onError(x, frame, err)
+		}
+		if fl != flowContinue {
+			return v.next(fl)
+		}
 	}
 
-	// Resolve the children fields.
-	if shouldResolveNestedDirectives(ctx, root) {
-		// If the root is a pointer, we need to allocate memory for it when it's
-		// not instantiated yet. We only expect it's a one-level pointer, e.g.
-		// *User, not **User.
-		underlying := rootValue
-		if root.Type.Kind() == reflect.Ptr {
-			if rootValue.Elem().IsNil() { // instantiate the pointer on demand
-				rootValue.Elem().Set(reflect.New(root.Type.Elem()))
+	// Resolve the value held by the interface field.
+	if frame.mode == selectAll && shouldResolveDynamicType(v.ctx, x) {
+		set, err := x.resolveDynamicType(v.ctx, rv)
+		frame.nestedSet = set
+		if err == ErrStop {
+			return v.next(flowStop)
+		} else if err != nil {
+			return v.onError(x, frame, err)
+		}
+		return WalkSkipChildren, nil
+	}
+
+	if !shouldResolveNestedDirectives(v.ctx, x) {
+		return WalkSkipChildren, nil
+	}
+
+	// If the field is a pointer, we need to allocate memory for it when it's
+	// not instantiated yet. We only expect it's a one-level pointer, e.g.
+	// *User, not **User.
+	frame.underlying = rv
+	if x.Type.Kind() == reflect.Ptr {
+		if rv.Elem().IsNil() { // instantiate the pointer on demand
+			rv.Elem().Set(reflect.New(x.Type.Elem()))
+			frame.allocated = true
+		}
+		frame.underlying = rv.Elem()
+	}
+	if frame.mode == selectPass {
+		return WalkContinue, nil // only the selected children are resolved
+	}
+
+	// Run the struct-level directives on the struct value, i.e. *T.
+	if fl, err = x.runStructDirectives(v.ctx, frame.underlying, phaseEnter); err != nil {
+		return v.onError(x, frame, err)
+	}
+	return v.next(fl)
+}
+
+func (v *resolveVisitor) Leave(x *Resolver, depth int) (WalkAction, error) {
+	frame := v.frames[len(v.frames)-1]
+	v.frames = v.frames[:len(v.frames)-1]
+	if frame.failed || frame.mode == selectNone {
+		return WalkContinue, nil
+	}
+
+	if frame.mode == selectAll {
+		if frame.underlying.IsValid() {
+			fl, err := x.runStructDirectives(v.ctx, frame.underlying, phaseLeave)
+			if err != nil {
+				return v.onError(x, frame, err)
 			}
-			underlying = rootValue.Elem()
-		}
-		for _, child := range root.Children {
-			if err := child.resolve(ctx, underlying.Elem().Field(child.Index[len(child.Index)-1]).Addr()); err != nil {
-				return &ResolveError{
-					fieldError: fieldError{
-						Err:      err,
-						Resolver: child,
-					},
-				}
+			if fl == flowStop {
+				return v.next(fl)
 			}
 		}
+		_, fl, err := v.runDirectives(x, frame, frame.ctx, phaseLeave)
+		if err != nil {
+			return v.onError(x, frame, err)
+		}
+		if fl == flowStop {
+			return v.next(fl)
+		}
 	}
-	return nil
+
+	// No value has been set into the pointer we instantiated, reset it to nil
+	// to tell "absent" from "present".
+	if frame.allocated && isLazyAllocationEnabled(v.ctx, x) && !frame.set && !frame.nestedSet {
+		frame.rv.Elem().Set(reflect.Zero(x.Type))
+	}
+	v.set = v.set || frame.set || frame.nestedSet
+	if len(v.frames) > 0 {
+		parent := v.frames[len(v.frames)-1]
+		parent.nestedSet = parent.nestedSet || frame.set || frame.nestedSet
+	}
+	return WalkContinue, nil
+}
+
+// runDirectives runs the directives of the field in the given phase, and
+// tracks whether they set a value, see WithLazyAllocation. A value is set if
+// any executor reported so, or the field value changed.
+func (v *resolveVisitor) runDirectives(x *Resolver, frame *resolveFrame, ctx context.Context, p phase) (context.Context, flow, error) {
+	if !isLazyAllocationEnabled(v.ctx, x) {
+		ctx, fl, _, err := x.runDirectives(ctx, frame.rv, p)
+		return ctx, fl, err
+	}
+
+	before := snapshot(frame.rv)
+	ctx, fl, set, err := x.runDirectives(ctx, frame.rv, p)
+	if err == nil && (set || !reflect.DeepEqual(before.Interface(), frame.rv.Elem().Interface())) {
+		frame.set = true
+	}
+	return ctx, fl, err
 }
 
-func (r *Resolver) runDirectives(ctx context.Context, rv reflect.Value) error {
-	ns := r.Namespace()
+// snapshot copies the field value rv points to. If the field is a pointer, the
+// value it points to is copied as well, to tell the changes made through it.
+func snapshot(rv reflect.Value) reflect.Value {
+	if v := rv.Elem(); v.Kind() == reflect.Ptr && !v.IsNil() {
+		return clonePointer(v)
+	}
+	cp := reflect.New(rv.Type().Elem()).Elem()
+	cp.Set(rv.Elem())
+	return cp
+}
+
+// next returns the walk action of the control flow requested by the executors.
+func (v *resolveVisitor) next(fl flow) (WalkAction, error) {
+	switch fl {
+	case flowStop:
+		v.stopped = true
+		return WalkStop, nil
+	case flowSkipChildren:
+		return WalkSkipChildren, nil
+	}
+	return WalkContinue, nil
+}
 
-	// The namespace can be overriden by calling Scan/Resolve with WithNamespace.
+// onError handles the error of a field. Stops the resolution if fail-fast.
+// Otherwise, collects the error and skips the subtree of the field, until the
+// number of the errors reaches the cap, see WithMaxErrors.
+func (v *resolveVisitor) onError(x *Resolver, frame *resolveFrame, err error) (WalkAction, error) {
+	err = v.fail(x, err)
+	if v.failFast {
+		return WalkStop, err
+	}
+	v.errs = append(v.errs, err)
+	frame.failed = true
+	if v.maxErrors > 0 && len(v.errs) >= v.maxErrors {
+		return WalkStop, nil
+	}
+	return WalkSkipChildren, nil
+}
+
+// fail wraps the error by a ResolveError for each field on the path from the
+// failed field up to (excluding) the start of the resolution.
+func (v *resolveVisitor) fail(x *Resolver, err error) error {
+	for ; x != v.start; x = x.Parent {
+		err = &ResolveError{
+			fieldError: fieldError{
+				Err:      err,
+				Resolver: x,
+			},
+		}
+	}
+	return err
+}
+
+// phase tells when the directives run while visiting a field.
+type phase int
+
+const (
+	phaseEnter phase = iota // before the children, i.e. pre-order
+	phaseLeave              // after the children, i.e. post-order
+)
+
+// flow is the control flow requested by the executors, see ErrSkipChildren
+// and ErrStop.
+type flow int
+
+const (
+	flowContinue flow = iota
+	flowSkipChildren
+	flowStop
+)
+
+// runDirectives runs the directives of the field in the given phase. Returns
+// the context passed along the directives, and whether any executor reported a
+// value set, see DirectiveRuntime.MarkValueSet.
+func (r *Resolver) runDirectives(ctx context.Context, rv reflect.Value, p phase) (context.Context, flow, bool, error) {
+	return r.executeDirectives(ctx, r.directivesIn(ctx, r.Directives, p), rv)
+}
+
+// runStructDirectives runs the struct-level directives in the given phase. On
+// entering the struct, the pre-order ones of PreDirectives run. On leaving, the
+// post-order ones of PreDirectives, and all the PostDirectives run.
+func (r *Resolver) runStructDirectives(ctx context.Context, sv reflect.Value, p phase) (flow, error) {
+	directives := r.directivesIn(ctx, r.PreDirectives, p)
+	if p == phaseLeave {
+		directives = append(directives, r.PostDirectives...)
+	}
+	_, fl, _, err := r.executeDirectives(ctx, directives, sv)
+	return fl, err
+}
+
+// directivesIn filters the directives running in the given phase.
+func (r *Resolver) directivesIn(ctx context.Context, directives []*Directive, p phase) []*Directive {
+	ns := r.namespaceIn(ctx)
+	var filtered []*Directive
+	for _, d := range directives {
+		if isPostOrder(d, ns.LookupExecutor(d.Name)) == (p == phaseLeave) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// namespaceIn returns the namespace to look up the executors. The namespace can
+// be overriden by calling Scan/Resolve with WithNamespace.
+func (r *Resolver) namespaceIn(ctx context.Context) *Namespace {
 	if nsOverriden := ctx.Value(ckNamespace); nsOverriden != nil {
-		ns = nsOverriden.(*Namespace)
+		return nsOverriden.(*Namespace)
 	}
+	return r.Namespace()
+}
+
+// executeDirectives runs the given directives in order. Returns the context
+// passed along the directives, the control flow requested by the executors,
+// which returned the sentinel errors, e.g. ErrSkipChildren, and whether any
+// executor reported a value set.
+func (r *Resolver) executeDirectives(ctx context.Context, directives []*Directive, rv reflect.Value) (context.Context, flow, bool, error) {
+	ns := r.namespaceIn(ctx)
+	observer := observerOf(ctx, r)
+	recoverPanics := isPanicRecoveryEnabled(ctx, r)
+	fl := flowContinue
+	set := false
+	for _, directive := range directives {
+		if err := ctx.Err(); err != nil {
+			return ctx, fl, set, fmt.Errorf("stopped before executing directive %q: %w", directive.Name, err)
+		}
 
-	for _, directive := range r.Directives {
 		dirRuntime := &DirectiveRuntime{
 			Directive: directive,
 			Resolver:  r,
@@ -350,14 +1222,45 @@ func (r *Resolver) runDirectives(ctx context.Context, rv reflect.Value) error {
 		}
 		exe := ns.LookupExecutor(directive.Name)
 		if exe == nil {
-			return &DirectiveExecutionError{
+			return ctx, fl, set, &DirectiveExecutionError{
 				Err:       ErrMissingExecutor,
 				Directive: *directive,
 			}
 		}
 
-		if err := exe.Execute(dirRuntime); err != nil {
-			return &DirectiveExecutionError{
+		var start time.Time
+		if observer != nil {
+			observer.DirectiveStart(ctx, r, directive)
+			start = time.Now()
+		}
+		var err error
+		if recoverPanics {
+			err = executeRecovered(exe, dirRuntime)
+		} else {
+			err = exe.Execute(dirRuntime)
+		}
+		if observer != nil {
+			observer.DirectiveEnd(ctx, r, directive, time.Since(start), err)
+		}
+		set = set || dirRuntime.valueSet
+		if _, panicked := err.(*ExecutorPanicError); panicked {
+			// A panic is never taken as the control flow, even if the recovered
+			// value wraps the sentinel errors, e.g. ErrStop.
+			return ctx, fl, set, &DirectiveExecutionError{
+				Err:       err,
+				Directive: *directive,
+			}
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrSkipRemainingDirectives):
+			return dirRuntime.Context, fl, set, nil
+		case errors.Is(err, ErrSkipChildren):
+			fl = flowSkipChildren
+		case errors.Is(err, ErrStop):
+			return dirRuntime.Context, flowStop, set, nil
+		default:
+			return ctx, fl, set, &DirectiveExecutionError{
 				Err:       err,
 				Directive: *directive,
 			}
@@ -366,7 +1269,18 @@ func (r *Resolver) runDirectives(ctx context.Context, rv reflect.Value) error {
 		ctx = dirRuntime.Context // make the context available to the next directive
 	}
 
-	return nil
+	return ctx, fl, set, nil
+}
+
+// executeRecovered runs the executor, and turns its panic into an error, see
+// WithPanicRecovery.
+func executeRecovered(exe DirectiveExecutor, rtm *DirectiveRuntime) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &ExecutorPanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return exe.Execute(rtm)
 }
 
 func (r *Resolver) DebugLayoutText(depth int) string {
@@ -386,28 +1300,35 @@ func (r *Resolver) DebugLayoutText(depth int) string {
 
 // buildAndCacheResolverTree returns the tree with minimum settings (without any
 // options applied). It will load from cache if possible. Otherwise, it will
-// build the tree from scratch and cache it.
-func buildAndCacheResolverTree(typ reflect.Type) (tree *Resolver, err error) {
-	if builtTree, ok := builtTrees.Load(typ); ok { // hit cache
-		return builtTree.(*Resolver), nil
+// build the tree from scratch and cache it. The overlays are applied while
+// building, thus the cache key includes the fingerprint of them. So does the
+// dynamic flag, which tells whether to keep the untagged interface fields.
+func buildAndCacheResolverTree(cache TreeCache, overlays *OverlaySet, typ reflect.Type, dynamic bool) (tree *Resolver, err error) {
+	overlays.mu.RLock() // hold the overlays unchanged during the build
+	defer overlays.mu.RUnlock()
+
+	key := TreeCacheKey{Type: typ, Overlay: overlays.fingerprint(), Dynamic: dynamic}
+	if builtTree, ok := cache.Load(key); ok { // hit cache
+		return builtTree, nil
 	}
 
-	tree, err = buildResolverTree(typ) // build from scratch
+	tree, err = buildResolverTree(typ, overlays, dynamic) // build from scratch
 	if err != nil {
 		return nil, err
 	}
 
 	// Build successfully, cache it.
-	builtTrees.Store(typ, tree)
+	cache.Store(key, tree)
 	return tree, nil
 }
 
-// buildResolverTree builds a resolver tree from a struct type.
-func buildResolverTree(typ reflect.Type) (*Resolver, error) {
-	return buildResolver(typ, reflect.StructField{}, nil)
+// buildResolverTree builds a resolver tree from a struct type. The untagged
+// interface fields are kept only if dynamic, see WithDynamicTypesEnabled.
+func buildResolverTree(typ reflect.Type, overlays *OverlaySet, dynamic bool) (*Resolver, error) {
+	return buildResolver(typ, reflect.StructField{}, nil, nil, overlays, dynamic)
 }
 
-func buildResolver(typ reflect.Type, field reflect.StructField, parent *Resolver) (*Resolver, error) {
+func buildResolver(typ reflect.Type, field reflect.StructField, directives []*Directive, parent *Resolver, overlays *OverlaySet, dynamic bool) (*Resolver, error) {
 	root := &Resolver{
 		Type:    typ,
 		Field:   field,
@@ -417,13 +1338,11 @@ func buildResolver(typ reflect.Type, field reflect.StructField, parent *Resolver
 	}
 
 	if !root.IsRoot() {
-		directives, err := ParseTag(field.Tag.Get(Tag()))
-		if err != nil {
-			return nil, fmt.Errorf("parse directives (tag): %w", err)
-		}
 		root.Directives = directives
-		root.Path = append(root.Parent.Path, field.Name)
-		root.Index = append(root.Parent.Index, field.Index...)
+		// NOTE: always allocate new slices, the siblings must not share the
+		// underlying arrays with each other.
+		root.Path = append(append(make([]string, 0, len(parent.Path)+1), parent.Path...), field.Name)
+		root.Index = append(append(make([]int, 0, len(parent.Index)+1), parent.Index...), field.Index...)
 	}
 
 	if typ.Kind() == reflect.Ptr {
@@ -431,9 +1350,19 @@ func buildResolver(typ reflect.Type, field reflect.StructField, parent *Resolver
 	}
 
 	if typ.Kind() == reflect.Struct {
+		declared := false // whether any non-blank fields were declared
 		for i := 0; i < typ.NumField(); i++ {
 			field := typ.Field(i)
 
+			// The blank fields declare the struct-level directives.
+			if field.Name == BlankField {
+				if err := root.addStructDirectives(field, declared); err != nil {
+					return nil, fmt.Errorf("build resolver for %q failed: %w", root.PathString(), err)
+				}
+				continue
+			}
+			declared = true
+
 			// Skip unexported fields. Because we can't set value to them, nor
 			// get value from them by reflection.
 			if !field.IsExported() {
@@ -443,14 +1372,27 @@ func buildResolver(typ reflect.Type, field reflect.StructField, parent *Resolver
 				continue
 			}
 
-			child, err := buildResolver(field.Type, field, root)
+			path := strings.Join(append(append([]string{}, root.Path...), field.Name), ".")
+			directives, excluded, err := fieldDirectives(root, field, overlays)
 			if err != nil {
-				path := append(root.Path, field.Name)
-				return nil, fmt.Errorf("build resolver for %q failed: %w", strings.Join(path, "."), err)
+				return nil, fmt.Errorf("build resolver for %q failed: %w", path, err)
+			}
+			// Skip the field and its whole subtree if it is excluded by the
+			// reserved marker, i.e. `owl:"-"`.
+			if excluded {
+				continue
 			}
 
-			// Skip the field if it has no children and no directives.
-			if len(child.Children) > 0 || len(child.Directives) > 0 {
+			child, err := buildResolver(field.Type, field, directives, root, overlays, dynamic)
+			if err != nil {
+				return nil, fmt.Errorf("build resolver for %q failed: %w", path, err)
+			}
+
+			// Skip the field if it has no children and no directives. Except
+			// for interface fields, which can hold a struct at runtime, if the
+			// dynamic types are enabled.
+			if len(child.Children) > 0 || len(child.Directives) > 0 || child.hasStructDirectives() ||
+				(dynamic && child.Type.Kind() == reflect.Interface) {
 				root.Children = append(root.Children, child)
 			}
 		}
@@ -458,6 +1400,110 @@ func buildResolver(typ reflect.Type, field reflect.StructField, parent *Resolver
 	return root, nil
 }
 
+// addStructDirectives adds the directives declared by a blank field to the
+// struct-level directives. The ones declared before the other fields run
+// before the children, otherwise after.
+func (r *Resolver) addStructDirectives(field reflect.StructField, afterFields bool) error {
+	directives, err := ParseTag(field.Tag.Get(Tag()))
+	if err != nil {
+		return fmt.Errorf("parse struct directives (tag): %w", err)
+	}
+	if afterFields {
+		r.PostDirectives = append(r.PostDirectives, directives...)
+		return validateDirectives(r.PostDirectives)
+	}
+	r.PreDirectives = append(r.PreDirectives, directives...)
+	return validateDirectives(r.PreDirectives)
+}
+
+// fieldDirectives returns the directives of the field, which are parsed from
+// the struct tag, overlaid by the overlays, and then added the type-level
+// default directives. Also reports whether the field is excluded from the tree.
+func fieldDirectives(parent *Resolver, field reflect.StructField, overlays *OverlaySet) (directives []*Directive, excluded bool, err error) {
+	tag, err := parseFieldTag(field.Tag.Get(Tag()))
+	if err != nil {
+		return nil, false, fmt.Errorf("parse directives (tag): %w", err)
+	}
+
+	for _, overlay := range overlays.match(parent, field) {
+		tag = overlay.apply(tag)
+	}
+	if tag.excluded {
+		return nil, true, nil
+	}
+
+	directives = tag.withDefaults(overlays.defaultDirectives(field.Type))
+
+	// The directives from the overlays are shared, copy them.
+	for i, d := range directives {
+		directives[i] = d.Copy()
+	}
+	return directives, false, nil
+}
+
+// fieldTag is the parsed struct tag of a field.
+type fieldTag struct {
+	directives []*Directive
+	suppressed []string // names of the type-level default directives to suppress
+	excluded   bool
+}
+
+// parseFieldTag parses the struct tag of a field. Besides the directives, the
+// tag can be ExcludeMarker, or contain "-name" parts to suppress the
+// type-level default directives. Ex:
+//
+//	CreatedAt time.Time `owl:"form=created_at;-format"`
+func parseFieldTag(tag string) (*fieldTag, error) {
+	if strings.TrimSpace(tag) == ExcludeMarker {
+		return &fieldTag{excluded: true}, nil
+	}
+
+	parsed := &fieldTag{}
+	var rest []string
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "-") {
+			rest = append(rest, part)
+			continue
+		}
+		name := part[1:]
+		if !isValidDirectiveName(name) {
+			return nil, invalidDirectiveName(part)
+		}
+		parsed.suppressed = append(parsed.suppressed, name)
+	}
+
+	directives, err := ParseTag(strings.Join(rest, ";"))
+	if err != nil {
+		return nil, err
+	}
+	parsed.directives = directives
+	return parsed, nil
+}
+
+// withDefaults returns the directives of the tag, with the default directives
+// appended. The defaults suppressed or overridden (having the same names as
+// the directives in the tag) are left out.
+func (t *fieldTag) withDefaults(defaults []*Directive) []*Directive {
+	directives := append([]*Directive{}, t.directives...)
+	for _, d := range defaults {
+		if indexOfDirective(directives, d.Name) >= 0 || isSuppressed(t.suppressed, d.Name) {
+			continue
+		}
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+func isSuppressed(suppressed []string, name string) bool {
+	for _, s := range suppressed {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseTag creates a slice of Directive instances by parsing a struct tag.
 //
 // Runs ParseDirective() for all parts of a field's tag string (from a reflected ast.Field for example)
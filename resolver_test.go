@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/ggicci/owl"
@@ -230,6 +232,77 @@ func TestNew_SkipFieldsHavingNoDirectives(t *testing.T) {
 	))
 }
 
+func TestNew_ExcludeMarker(t *testing.T) {
+	type Form struct {
+		UserSignUpForm `owl:"-"`
+		Pagination     *Pagination `owl:" - "`
+		Token          string      `owl:"form=token"`
+	}
+
+	ns, tracker := createNsForTracking()
+	tree, err := owl.New(Form{}, owl.WithNamespace(ns))
+	assert.NoError(t, err)
+	assert.Len(t, tree.Children, 1)
+	assert.Nil(t, tree.Lookup("UserSignUpForm"))
+	assert.Nil(t, tree.Lookup("Pagination"))
+
+	_, err = tree.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, []*owl.Directive{owl.NewDirective("form", "token")}, tracker.Executed.ExecutedDirectives())
+}
+
+func TestNew_WithExcludedFields(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+
+	tree, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns), owl.WithExcludedFields("User.Gender", "CSRFToken"))
+	assert.NoError(err)
+	assert.Nil(tree.Lookup("User.Gender"))
+	assert.Nil(tree.Lookup("CSRFToken"))
+
+	_, err = tree.Resolve()
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "user"),
+		owl.NewDirective("form", "name"),
+		owl.NewDirective("form", "birthday"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Patterns are supported.
+	tree, err = owl.New(UserSignUpForm{}, owl.WithExcludedFields("**.*Name", "User"))
+	assert.NoError(err)
+	assert.Equal([]string{"CSRFToken"}, pathsOf(tree.Filter(func(r *owl.Resolver) bool { return !r.IsRoot() })))
+
+	// The cached tree is not affected.
+	tree, err = owl.New(UserSignUpForm{})
+	assert.NoError(err)
+	assert.NotNil(tree.Lookup("User.Gender"))
+
+	_, err = owl.New(UserSignUpForm{}, owl.WithExcludedFields("User.["))
+	assert.ErrorContains(err, "exclude fields")
+}
+
+func TestNew_DeepNestedSiblings(t *testing.T) {
+	type Level4 struct {
+		X string `owl:"form=x"`
+		Y string `owl:"form=y"`
+	}
+	type Level3 struct{ P, Q Level4 }
+	type Level2 struct{ C Level3 }
+	type Level1 struct{ B Level2 }
+
+	resolver, err := owl.New(Level1{})
+	assert.NoError(t, err)
+
+	// The siblings must not share the underlying arrays of Path and Index.
+	x := resolver.Lookup("B.C.P.X")
+	assert.Equal(t, []string{"B", "C", "P", "X"}, x.Path)
+	assert.Equal(t, []int{0, 0, 0, 0}, x.Index)
+	y := resolver.Lookup("B.C.Q.Y")
+	assert.Equal(t, []string{"B", "C", "Q", "Y"}, y.Path)
+	assert.Equal(t, []int{0, 0, 1, 1}, y.Index)
+}
+
 func TestNew_WithNilType(t *testing.T) {
 	_, err := owl.New(nil)
 	assert.ErrorIs(t, err, owl.ErrUnsupportedType)
@@ -336,6 +409,53 @@ func TestNew_copyCachedResolver(t *testing.T) {
 	assert.Equal(r2.Lookup("Appearance.Size").Parent, r2.Lookup("Appearance"))
 }
 
+func TestLookupByIndex(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	assert.Same(t, resolver, resolver.LookupByIndex(nil))
+	assert.Same(t, resolver.Lookup("User.Gender"), resolver.LookupByIndex([]int{0, 1}))
+	assert.Same(t, resolver.Lookup("CSRFToken"), resolver.LookupByIndex([]int{1}))
+	assert.Same(t, resolver.Lookup("User.Gender"), resolver.Lookup("User").LookupByIndex([]int{1}))
+	assert.Nil(t, resolver.LookupByIndex([]int{0, 3}))
+
+	for _, expected := range expectedUserSignUpFormResolverTree {
+		assert.Equal(t, expected.LookupPath, resolver.LookupByIndex(expected.Index).PathString())
+	}
+}
+
+func TestLookupByPointer(t *testing.T) {
+	type Query struct {
+		Keyword    string      `owl:"form=q"`
+		Pagination *Pagination `owl:"form=page"`
+		Form       UserSignUpForm
+	}
+
+	resolver, err := owl.New(Query{})
+	assert.NoError(t, err)
+
+	query := &Query{Pagination: &Pagination{}}
+	assert.Same(t, resolver, resolver.LookupByPointer(query, query))
+	assert.Equal(t, "Keyword", resolver.LookupByPointer(query, &query.Keyword).PathString())
+	assert.Equal(t, "Pagination", resolver.LookupByPointer(query, &query.Pagination).PathString())
+	assert.Equal(t, "Pagination.Page", resolver.LookupByPointer(query, &query.Pagination.Page).PathString())
+	assert.Equal(t, "Pagination.Size", resolver.LookupByPointer(query, &query.Pagination.Size).PathString())
+	assert.Equal(t, "Form.User", resolver.LookupByPointer(query, &query.Form.User).PathString())
+	assert.Equal(t, "Form.User.Name", resolver.LookupByPointer(query, &query.Form.User.Name).PathString())
+	assert.Equal(t, "Form.User.Gender", resolver.Lookup("Form").LookupByPointer(&query.Form, &query.Form.User.Gender).PathString())
+
+	// Not found.
+	another := &Query{Pagination: &Pagination{}}
+	assert.Nil(t, resolver.LookupByPointer(query, &another.Keyword))
+	assert.Nil(t, resolver.LookupByPointer(&Query{}, &query.Pagination.Page))
+
+	// Mismatch.
+	assert.Nil(t, resolver.LookupByPointer(*query, &query.Keyword))
+	assert.Nil(t, resolver.LookupByPointer(query.Pagination, &query.Pagination.Page))
+	assert.Nil(t, resolver.LookupByPointer(query, query.Keyword))
+	assert.Nil(t, resolver.LookupByPointer(query, nil))
+}
+
 func TestRemoveDirective(t *testing.T) {
 	type User struct {
 		Name string `owl:"form=name;query=name;header=X-Name;required"`
@@ -361,6 +481,115 @@ func TestRemoveDirective(t *testing.T) {
 	assert.Equal(t, "required", required.Name)
 	assert.Len(t, required.Argv, 0)
 	assert.Nil(t, nameResolver.GetDirective("required"))
+
+	header, err := nameResolver.DeleteDirective("header")
+	assert.NoError(t, err)
+	assert.Equal(t, "header", header.Name)
+	_, err = nameResolver.DeleteDirective("header")
+	assert.ErrorIs(t, err, owl.ErrDirectiveNotFound)
+}
+
+func TestDirectiveMutators(t *testing.T) {
+	assert := assert.New(t)
+	type User struct {
+		Name string `owl:"form=name;required"`
+	}
+
+	resolver, err := owl.New(User{})
+	assert.NoError(err)
+	name := resolver.Lookup("Name")
+
+	assert.NoError(name.AddDirective(owl.NewDirective("default", "owl")))
+	assert.NoError(name.InsertDirective(0, owl.NewDirective("header", "x-name")))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("header", "x-name"),
+		owl.NewDirective("form", "name"),
+		owl.NewDirective("required"),
+		owl.NewDirective("default", "owl"),
+	}, name.Directives)
+
+	assert.NoError(name.ReplaceDirective("form", owl.NewDirective("query", "name")))
+	assert.Equal(owl.NewDirective("query", "name"), name.Directives[1])
+	assert.Nil(name.GetDirective("form"))
+
+	assert.NoError(name.SetDirectives(owl.NewDirective("form", "username")))
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "username")}, name.Directives)
+
+	// Invalid mutations.
+	assert.ErrorIs(name.AddDirective(owl.NewDirective("form")), owl.ErrDuplicateDirective)
+	assert.ErrorIs(name.AddDirective(owl.NewDirective("in/valid")), owl.ErrInvalidDirectiveName)
+	assert.ErrorContains(name.AddDirective(nil), "nil directive")
+	assert.ErrorContains(name.InsertDirective(2, owl.NewDirective("required")), "out of range")
+	assert.ErrorContains(name.InsertDirective(-1, owl.NewDirective("required")), "out of range")
+	assert.ErrorIs(name.ReplaceDirective("required", owl.NewDirective("form")), owl.ErrDirectiveNotFound)
+	assert.ErrorIs(name.SetDirectives(owl.NewDirective("form"), owl.NewDirective("form")), owl.ErrDuplicateDirective)
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "username")}, name.Directives)
+}
+
+func TestFreeze(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+	assert.False(resolver.IsFrozen())
+
+	// Freeze the whole tree from any node.
+	resolver.Lookup("User.Name").Freeze()
+	assert.NoError(resolver.Iterate(func(r *owl.Resolver) error {
+		assert.True(r.IsFrozen())
+		return nil
+	}))
+
+	gender := resolver.Lookup("User.Gender")
+	assert.ErrorIs(gender.AddDirective(owl.NewDirective("required")), owl.ErrFrozenTree)
+	assert.ErrorIs(gender.InsertDirective(0, owl.NewDirective("required")), owl.ErrFrozenTree)
+	assert.ErrorIs(gender.ReplaceDirective("form", owl.NewDirective("query")), owl.ErrFrozenTree)
+	assert.ErrorIs(gender.SetDirectives(), owl.ErrFrozenTree)
+	assert.Nil(gender.RemoveDirective("form"))
+	_, err = gender.DeleteDirective("form")
+	assert.ErrorIs(err, owl.ErrFrozenTree)
+	assert.Len(gender.Directives, 2)
+
+	// The copy is mutable.
+	copied := resolver.Copy()
+	assert.False(copied.IsFrozen())
+	assert.NoError(copied.Lookup("User.Gender").AddDirective(owl.NewDirective("env", "GENDER")))
+
+	// Implicitly frozen by Resolve, ResolveTo and Scan.
+	for _, run := range []func(r *owl.Resolver) error{
+		func(r *owl.Resolver) error { _, err := r.Resolve(); return err },
+		func(r *owl.Resolver) error { return r.ResolveTo(&UserSignUpForm{}) },
+		func(r *owl.Resolver) error { return r.Scan(UserSignUpForm{}) },
+	} {
+		tree := copied.Copy()
+		assert.NoError(run(tree))
+		assert.True(tree.Lookup("User.Gender").IsFrozen())
+		assert.ErrorIs(tree.Lookup("User.Gender").AddDirective(owl.NewDirective("required")), owl.ErrFrozenTree)
+	}
+	assert.Contains(tracker.Executed.ExecutedDirectives(), owl.NewDirective("env", "GENDER"))
+}
+
+func TestFreeze_ConcurrentCopy(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	// Resolve freezes the tree implicitly, while Copy reads it.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := resolver.Resolve()
+		assert.NoError(err)
+	}()
+	go func() {
+		defer wg.Done()
+		assert.False(resolver.Copy().IsFrozen())
+	}()
+	wg.Wait()
+	assert.True(resolver.IsFrozen())
 }
 
 func TestResolve_SimpleFlatStruct(t *testing.T) {
@@ -677,6 +906,125 @@ func TestResolveTo_InstantializeOnlyNilPointerForNestedStruct(t *testing.T) {
 	os.Clearenv()
 }
 
+func TestResolve_WithLazyAllocation(t *testing.T) {
+	assert := assert.New(t)
+
+	type Address struct {
+		City string `owl:"env=OWL_TEST_CITY"`
+	}
+	type Profile struct {
+		Name    string `owl:"env=OWL_TEST_NAME"`
+		Address *Address
+	}
+
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("env", owl.DirectiveExecutorFunc(exeEnvReader))
+	resolver, err := owl.New(Profile{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	os.Setenv("OWL_TEST_NAME", "owl")
+	defer os.Clearenv()
+
+	// By default, the nil pointer is always instantiated.
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal(&Profile{Name: "owl", Address: &Address{}}, gotValue.Interface())
+
+	// Nothing resolved into Address, keep it nil.
+	gotValue, err = resolver.Resolve(owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	assert.Equal(&Profile{Name: "owl"}, gotValue.Interface())
+
+	// The option can also be set in New.
+	lazyResolver, err := owl.New(Profile{}, owl.WithNamespace(ns), owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	gotValue, err = lazyResolver.Resolve()
+	assert.NoError(err)
+	assert.Nil(gotValue.Interface().(*Profile).Address)
+
+	// And overridden in Resolve.
+	gotValue, err = lazyResolver.Resolve(owl.WithLazyAllocation(false))
+	assert.NoError(err)
+	assert.NotNil(gotValue.Interface().(*Profile).Address)
+
+	// Something resolved into Address, keep it allocated.
+	os.Setenv("OWL_TEST_CITY", "Hangzhou")
+	gotValue, err = resolver.Resolve(owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	assert.Equal(&Profile{Name: "owl", Address: &Address{City: "Hangzhou"}}, gotValue.Interface())
+
+	// The pointer which was not instantiated by Resolve is left untouched.
+	os.Unsetenv("OWL_TEST_CITY")
+	address := &Address{}
+	profile := &Profile{Address: address}
+	assert.NoError(resolver.ResolveTo(profile, owl.WithLazyAllocation(true)))
+	assert.Same(address, profile.Address)
+}
+
+func TestResolve_WithLazyAllocation_ZeroValue(t *testing.T) {
+	assert := assert.New(t)
+
+	type Addr struct {
+		Enabled bool `owl:"setfalse"`
+	}
+	type Config struct {
+		Addr *Addr
+	}
+
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("setfalse", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().SetBool(false)
+		rtm.MarkValueSet()
+		return nil
+	}))
+	resolver, err := owl.New(Config{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	// A zero value resolved is still present.
+	gotValue, err := resolver.Resolve(owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	assert.Equal(&Config{Addr: &Addr{Enabled: false}}, gotValue.Interface())
+
+	// A directive which ran but found nothing doesn't count.
+	ns.RegisterDirectiveExecutor("setfalse", owl.DirectiveExecutorFunc(exeNoop), true)
+	gotValue, err = resolver.Resolve(owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	assert.Equal(&Config{}, gotValue.Interface())
+}
+
+func TestResolve_WithLazyAllocation_DynamicTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	type Circle struct {
+		R int `owl:"set"`
+	}
+	type Address struct {
+		Shape any
+	}
+	type Profile struct {
+		Addr *Address
+	}
+
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("set", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().SetInt(7)
+		return nil
+	}))
+	resolver, err := owl.New(Profile{},
+		owl.WithNamespace(ns),
+		owl.WithDynamicTypesEnabled(true),
+		owl.WithConcreteTypeFunc(func(r *owl.Resolver, ctx context.Context) reflect.Type {
+			return reflect.TypeOf(&Circle{})
+		}),
+	)
+	assert.NoError(err)
+
+	// The values set in the dynamic types count.
+	gotValue, err := resolver.Resolve(owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	assert.Equal(&Profile{Addr: &Address{Shape: &Circle{R: 7}}}, gotValue.Interface())
+}
+
 func TestResolveTo_PopulateFieldsOnDemand(t *testing.T) {
 	type User struct {
 		Name string `owl:"env=OWL_TEST_NAME"`
@@ -1050,3 +1398,837 @@ func TestParseTag(t *testing.T) {
 		assert.ErrorIs(t, err, testcase.err)
 	}
 }
+
+type PasswordForm struct {
+	_        struct{} `owl:"prepare"`
+	Password string   `owl:"form=password"`
+	Confirm  string   `owl:"form=confirm"`
+	_        struct{} `owl:"equal=Password,Confirm"`
+}
+
+type ChangePasswordForm struct {
+	Token string        `owl:"form=token"`
+	New   *PasswordForm `owl:"form=new"`
+}
+
+// exeEqual checks whether the fields named by argv of a struct are equal.
+func exeEqual(rtm *owl.DirectiveRuntime) error {
+	sv := rtm.Value
+	for sv.Kind() == reflect.Ptr {
+		sv = sv.Elem()
+	}
+	if !sv.IsValid() {
+		return nil // nil struct
+	}
+	first := sv.FieldByName(rtm.Directive.Argv[0]).Interface()
+	for _, name := range rtm.Directive.Argv[1:] {
+		if sv.FieldByName(name).Interface() != first {
+			return fmt.Errorf("%s mismatch", name)
+		}
+	}
+	return nil
+}
+
+func TestNew_StructDirectives(t *testing.T) {
+	assert := assert.New(t)
+	resolver, err := owl.New(ChangePasswordForm{})
+	assert.NoError(err)
+
+	assert.Empty(resolver.PreDirectives)
+	assert.Len(resolver.Children, 2)
+	form := resolver.Lookup("New")
+	assert.Equal([]*owl.Directive{owl.NewDirective("prepare")}, form.PreDirectives)
+	assert.Equal([]*owl.Directive{owl.NewDirective("equal", "Password", "Confirm")}, form.PostDirectives)
+	assert.Len(form.Children, 2, "blank fields are not children")
+
+	// The struct directives alone keep the field in the tree.
+	type Wrapper struct {
+		Untagged struct {
+			_ struct{} `owl:"check"`
+			A string
+		}
+	}
+	resolver, err = owl.New(Wrapper{})
+	assert.NoError(err)
+	assert.NotNil(resolver.Lookup("Untagged"))
+
+	// Invalid struct directives.
+	type BadForm struct {
+		_ struct{} `owl:"check"`
+		_ struct{} `owl:"check"`
+	}
+	_, err = owl.New(BadForm{})
+	assert.ErrorIs(err, owl.ErrDuplicateDirective)
+}
+
+func TestResolve_StructDirectives(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking("prepare")
+	ns.RegisterDirectiveExecutor("equal", owl.DirectiveExecutorFunc(exeEqual))
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		if rtm.Value.Elem().Kind() == reflect.String {
+			rtm.Value.Elem().SetString(rtm.Directive.Argv[0])
+		}
+		return nil
+	}), true)
+
+	resolver, err := owl.New(ChangePasswordForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	// Runs before and after the children of the struct.
+	_, err = resolver.Resolve()
+	var de *owl.DirectiveExecutionError
+	assert.ErrorAs(err, &de)
+	assert.Equal("equal", de.Name)
+	assert.ErrorContains(err, "Confirm mismatch")
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "token"),
+		owl.NewDirective("form", "new"),
+		owl.NewDirective("prepare"),
+		owl.NewDirective("form", "password"),
+		owl.NewDirective("form", "confirm"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	var re *owl.ResolveError
+	assert.ErrorAs(err, &re)
+	assert.Equal("New", re.Resolver.PathString())
+
+	// The root struct.
+	tracker.Reset()
+	resolver, err = owl.New(PasswordForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+	_, err = resolver.Resolve()
+	assert.ErrorContains(err, "Confirm mismatch")
+	assert.Equal("prepare", tracker.Executed[0].Name)
+	assert.IsType(&PasswordForm{}, tracker.Executed[0].FieldValue, "runs on the pointer to the struct")
+}
+
+func TestScan_StructDirectives(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking("prepare")
+	ns.RegisterDirectiveExecutor("equal", owl.DirectiveExecutorFunc(exeEqual))
+
+	resolver, err := owl.New(ChangePasswordForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	form := &ChangePasswordForm{New: &PasswordForm{Password: "secret", Confirm: "secret"}}
+	assert.NoError(resolver.Scan(form))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "token"),
+		owl.NewDirective("form", "new"),
+		owl.NewDirective("prepare"),
+		owl.NewDirective("form", "password"),
+		owl.NewDirective("form", "confirm"),
+	}, tracker.Executed.ExecutedDirectives())
+	assert.Equal(*form.New, tracker.Executed[2].FieldValue, "runs on the struct value")
+
+	form.New.Confirm = "typo"
+	err = resolver.Scan(form)
+	var se *owl.ScanError
+	assert.ErrorAs(err, &se)
+	assert.Equal("New", se.Resolver.PathString())
+	assert.ErrorContains(err, "Confirm mismatch")
+
+	// Skip the nil struct.
+	tracker.Reset()
+	err = resolver.Scan(&ChangePasswordForm{})
+	assert.ErrorIs(err, owl.ErrScanNilField)
+	assert.NotContains(tracker.Executed.ExecutedDirectives(), owl.NewDirective("prepare"))
+}
+
+func TestResolve_PostOrder(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking("prepare")
+	ns.RegisterDirectiveExecutor("equal", owl.PostOrder(owl.DirectiveExecutorFunc(exeEqual)))
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		if rtm.Value.Elem().Kind() == reflect.String {
+			rtm.Value.Elem().SetString("secret")
+		}
+		return nil
+	}), true)
+	ns.RegisterDirectiveExecutor("check", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		if rtm.Context.Value("ctx") != "from form" {
+			return errors.New("context not passed along")
+		}
+		// Sees the fully resolved sub-object.
+		return exeEqual(rtm)
+	}))
+	ns.RegisterDirectiveExecutor("ctx", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Context = context.WithValue(rtm.Context, "ctx", "from form")
+		return nil
+	}))
+
+	type Form struct {
+		_        struct{} `owl:"equal=Password,Confirm"` // requested by the executor
+		Password string   `owl:"form=password"`
+		Confirm  string   `owl:"form=confirm"`
+	}
+	type Request struct {
+		Form *Form `owl:"after:check=Password,Confirm;form=form;ctx"`
+	}
+
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+	assert.Equal(&owl.Directive{Name: "check", Argv: []string{"Password", "Confirm"}, PostOrder: true}, resolver.Lookup("Form").Directives[0])
+
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal(&Request{Form: &Form{Password: "secret", Confirm: "secret"}}, gotValue.Interface())
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "form"),
+		owl.NewDirective("form", "password"),
+		owl.NewDirective("form", "confirm"),
+		{Name: "check", Argv: []string{"Password", "Confirm"}, PostOrder: true},
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Errors of the post-order directives.
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		if rtm.Directive.Argv[0] == "confirm" {
+			rtm.Value.Elem().SetString("typo")
+		}
+		return nil
+	}), true)
+	_, err = resolver.Resolve()
+	var de *owl.DirectiveExecutionError
+	assert.ErrorAs(err, &de)
+	assert.Equal("equal", de.Name)
+	var re *owl.ResolveError
+	assert.ErrorAs(err, &re)
+	assert.Equal("Form", re.Resolver.PathString())
+}
+
+func TestResolve_PostOrder_LazyAllocation(t *testing.T) {
+	assert := assert.New(t)
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("fill", owl.PostOrder(owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().Elem().FieldByName("Name").SetString("filled")
+		return nil
+	})))
+	ns.RegisterDirectiveExecutor("noop", owl.DirectiveExecutorFunc(exeNoop))
+
+	type Profile struct {
+		Name string `owl:"noop"`
+	}
+	type Request struct {
+		Profile *Profile `owl:"fill"`
+	}
+
+	// The post-order directives run before the pointer is reset.
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns), owl.WithLazyAllocation(true))
+	assert.NoError(err)
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal(&Request{Profile: &Profile{Name: "filled"}}, gotValue.Interface())
+}
+
+func TestScan_PostOrder(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking("prepare")
+	ns.RegisterDirectiveExecutor("equal", owl.DirectiveExecutorFunc(exeEqual))
+
+	type Request struct {
+		Form *PasswordForm `owl:"after:equal=Password,Confirm;form=form"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	request := &Request{Form: &PasswordForm{Password: "secret", Confirm: "secret"}}
+	assert.NoError(resolver.Scan(request))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "form"),
+		owl.NewDirective("prepare"),
+		owl.NewDirective("form", "password"),
+		owl.NewDirective("form", "confirm"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Both the struct-level and the post-order field directives fail.
+	request.Form.Confirm = "typo"
+	err = resolver.Scan(request)
+	var se *owl.ScanError
+	assert.ErrorAs(err, &se)
+	assert.Len(err.(interface{ Unwrap() []error }).Unwrap(), 2)
+
+	// The children of the nil struct failed.
+	tracker.Reset()
+	err = resolver.Scan(&Request{})
+	assert.ErrorIs(err, owl.ErrScanNilField)
+	assert.Equal([]*owl.Directive{owl.NewDirective("form", "form")}, tracker.Executed.ExecutedDirectives())
+}
+
+func TestResolveContext(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "request_id", "r1"))
+	defer cancel()
+
+	var executed []string
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		executed = append(executed, rtm.Directive.Argv[0])
+		if rtm.Context.Value("request_id") != "r1" {
+			return errors.New("missing request_id")
+		}
+		if rtm.Directive.Argv[0] == "user" {
+			cancel() // e.g. the client went away
+		}
+		return nil
+	}))
+	ns.RegisterDirectiveExecutor("default", owl.DirectiveExecutorFunc(exeNoop))
+	ns.RegisterDirectiveExecutor("env", owl.DirectiveExecutorFunc(exeNoop))
+
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	_, err = resolver.ResolveContext(ctx)
+	assert.ErrorIs(err, context.Canceled)
+	var re *owl.ResolveError
+	assert.ErrorAs(err, &re)
+	assert.Equal("User", re.Resolver.PathString())
+	assert.ErrorContains(err, `stopped before resolving "User.Name"`)
+	assert.Equal([]string{"user"}, executed)
+
+	// Already done.
+	executed = nil
+	err = resolver.ResolveToContext(ctx, &UserSignUpForm{})
+	assert.ErrorIs(err, context.Canceled)
+	assert.Empty(executed)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, err = resolver.ResolveContext(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.ErrorContains(err, `stopped before resolving "(root)"`)
+
+	// Resolve always runs with context.Background().
+	_, err = resolver.Resolve()
+	assert.ErrorContains(err, "missing request_id")
+}
+
+func TestScanContext(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var executed []string
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		executed = append(executed, rtm.Directive.Argv[0])
+		if rtm.Directive.Argv[0] == "name" {
+			cancel()
+		}
+		return nil
+	}))
+	ns.RegisterDirectiveExecutor("default", owl.DirectiveExecutorFunc(exeNoop))
+	ns.RegisterDirectiveExecutor("env", owl.DirectiveExecutorFunc(exeNoop))
+
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	err = resolver.ScanContext(ctx, &UserSignUpForm{})
+	assert.ErrorIs(err, context.Canceled)
+	assert.Len(err.(interface{ Unwrap() []error }).Unwrap(), 1, "reported once")
+	assert.Equal([]string{"user", "name"}, executed)
+
+	// Already done, and no directives at all.
+	resolver, err = owl.New(Pagination{}, owl.WithNamespace(owl.NewNamespace()))
+	assert.NoError(err)
+	err = resolver.ScanContext(ctx, Pagination{})
+	assert.ErrorIs(err, context.Canceled)
+	assert.ErrorContains(err, `stopped before scanning "(root)"`)
+}
+
+func TestResolve_WithFailFast_false(t *testing.T) {
+	assert := assert.New(t)
+	var executed []string
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		executed = append(executed, rtm.Directive.Argv[0])
+		if rtm.Directive.Argv[0] == "user" || rtm.Directive.Argv[0] == "csrf_token" {
+			return errors.New("invalid " + rtm.Directive.Argv[0])
+		}
+		return nil
+	}))
+	ns.RegisterDirectiveExecutor("default", owl.DirectiveExecutorFunc(exeNoop))
+	ns.RegisterDirectiveExecutor("env", owl.DirectiveExecutorFunc(exeNoop))
+
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	// By default, stops at the first failed field.
+	_, err = resolver.Resolve()
+	assert.ErrorContains(err, "invalid user")
+	assert.Equal([]string{"user"}, executed)
+
+	// Keep going, but don't descend into the failed field.
+	executed = nil
+	_, err = resolver.Resolve(owl.WithFailFast(false))
+	assert.Equal([]string{"user", "csrf_token"}, executed)
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	assert.Len(errs, 2)
+	var re *owl.ResolveError
+	assert.ErrorAs(errs[0], &re)
+	assert.Equal("User", re.Resolver.PathString())
+	assert.ErrorAs(errs[1], &re)
+	assert.Equal("CSRFToken", re.Resolver.PathString())
+
+	// Set in New.
+	resolver, err = owl.New(UserSignUpForm{}, owl.WithNamespace(ns), owl.WithFailFast(false))
+	assert.NoError(err)
+	assert.Len(resolver.ResolveTo(&UserSignUpForm{}).(interface{ Unwrap() []error }).Unwrap(), 2)
+
+	// No errors.
+	executed = nil
+	resolver, err = owl.New(Pagination{}, owl.WithNamespace(ns), owl.WithFailFast(false))
+	assert.NoError(err)
+	_, err = resolver.Resolve()
+	assert.NoError(err)
+}
+
+func TestResolve_WithFailFast_false_nested(t *testing.T) {
+	assert := assert.New(t)
+	ns := owl.NewNamespace()
+	ns.RegisterDirectiveExecutor("fail", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		return errors.New("failed")
+	}))
+	ns.RegisterDirectiveExecutor("set", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().SetString(rtm.Directive.Argv[0])
+		return nil
+	}))
+
+	type Inner struct {
+		A string `owl:"fail"`
+		B string `owl:"set=b"`
+		C string `owl:"after:fail"`
+	}
+	type Outer struct {
+		Inner Inner
+		D     string `owl:"set=d"`
+	}
+	resolver, err := owl.New(Outer{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	gotValue, err := resolver.Resolve(owl.WithFailFast(false))
+	assert.Len(err.(interface{ Unwrap() []error }).Unwrap(), 2)
+	assert.ErrorContains(err, `"Inner.A (string)"`)
+	assert.ErrorContains(err, `"Inner.C (string)"`)
+	assert.Equal(&Outer{Inner: Inner{B: "b"}, D: "d"}, gotValue.Interface())
+}
+
+func TestScan_WithFailFast(t *testing.T) {
+	ns, tracker := createNsForTrackingWithError(errors.New("TestScan_WithFailFast"))
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(t, err)
+
+	form := &UserSignUpForm{}
+	err = resolver.Scan(form, owl.WithFailFast(true))
+	assert.ErrorContains(t, err, "TestScan_WithFailFast")
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 1)
+	assert.Len(t, tracker.Executed, 1)
+
+	// Set in New.
+	resolver, err = owl.New(UserSignUpForm{}, owl.WithNamespace(ns), owl.WithFailFast(true))
+	assert.NoError(t, err)
+	assert.Len(t, resolver.Scan(form).(interface{ Unwrap() []error }).Unwrap(), 1)
+	assert.Len(t, resolver.Scan(form, owl.WithFailFast(false)).(interface{ Unwrap() []error }).Unwrap(), 5)
+}
+
+func TestScan_WithMaxErrors(t *testing.T) {
+	ns, tracker := createNsForTrackingWithError(errors.New("TestScan_WithMaxErrors"))
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(t, err)
+
+	err = resolver.Scan(&UserSignUpForm{}, owl.WithMaxErrors(3))
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 3)
+	assert.Len(t, tracker.Executed, 3)
+
+	// No cap.
+	err = resolver.Scan(&UserSignUpForm{}, owl.WithMaxErrors(0))
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 5)
+}
+
+func TestResolve_WithMaxErrors(t *testing.T) {
+	ns, tracker := createNsForTrackingWithError(errors.New("TestResolve_WithMaxErrors"))
+	type Form struct {
+		A string `owl:"form=a"`
+		B string `owl:"form=b"`
+		C string `owl:"form=c"`
+	}
+	resolver, err := owl.New(Form{}, owl.WithNamespace(ns), owl.WithFailFast(false))
+	assert.NoError(t, err)
+
+	_, err = resolver.Resolve(owl.WithMaxErrors(2))
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+	assert.Len(t, tracker.Executed, 2)
+}
+
+func TestResolve_ErrSkipRemainingDirectives(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking("query", "header")
+	ns.RegisterDirectiveExecutor("query", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		if rtm.Directive.Argv[0] == "found" {
+			rtm.Value.Elem().SetString("from query")
+			return owl.ErrSkipRemainingDirectives
+		}
+		return nil
+	}), true)
+	ns.RegisterDirectiveExecutor("check", owl.PostOrder(owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		return nil
+	})))
+
+	type Request struct {
+		Token string `owl:"query=found;header=token;after:check"`
+		Page  string `owl:"query=page;header=page"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal("from query", gotValue.Elem().Interface().(Request).Token)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("query", "found"),
+		{Name: "check", PostOrder: true}, // the post-order directives still run
+		owl.NewDirective("query", "page"),
+		owl.NewDirective("header", "page"),
+	}, tracker.Executed.ExecutedDirectives())
+}
+
+func TestResolve_ErrSkipChildren(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	ns.RegisterDirectiveExecutor("skip", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		return fmt.Errorf("no login: %w", owl.ErrSkipChildren) // wrapped
+	}))
+
+	type Login struct {
+		Username string `owl:"form=username"`
+	}
+	type Request struct {
+		Login *Login `owl:"skip;form=login"`
+		Page  string `owl:"form=page"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	_, err = resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("skip"),
+		owl.NewDirective("form", "login"), // the remaining directives still run
+		owl.NewDirective("form", "page"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	tracker.Reset()
+	assert.NoError(resolver.Scan(&Request{Login: &Login{}}))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("skip"),
+		owl.NewDirective("form", "login"),
+		owl.NewDirective("form", "page"),
+	}, tracker.Executed.ExecutedDirectives())
+}
+
+func TestResolve_ErrStop(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	ns.RegisterDirectiveExecutor("stop", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		return owl.ErrStop
+	}))
+
+	type Request struct {
+		Login struct {
+			Username string `owl:"form=username;stop;default=ignored"`
+			Password string `owl:"form=password"`
+		} `owl:"after:form=login"`
+		Page string `owl:"form=page"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	gotValue, err := resolver.Resolve()
+	assert.NoError(err)
+	assert.NotNil(gotValue.Interface())
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "username"),
+		owl.NewDirective("stop"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	tracker.Reset()
+	assert.NoError(resolver.Scan(&Request{}))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "username"),
+		owl.NewDirective("stop"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Stops the outer resolution from the dynamic subtrees.
+	tracker.Reset()
+	type Shape struct {
+		Name string `owl:"stop"`
+	}
+	type Canvas struct {
+		Shape any    `owl:"form=shape"`
+		Page  string `owl:"form=page"`
+	}
+	resolver, err = owl.New(Canvas{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+	canvas := &Canvas{Shape: &Shape{}}
+	assert.NoError(resolver.ResolveTo(canvas))
+	assert.NoError(resolver.Scan(canvas))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "shape"),
+		owl.NewDirective("stop"),
+		owl.NewDirective("form", "shape"),
+		owl.NewDirective("stop"),
+	}, tracker.Executed.ExecutedDirectives())
+}
+
+func TestResolve_WithOnlyPaths(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		tracker.Track(rtm.Directive, nil)
+		if rtm.Value.Elem().Kind() == reflect.Int {
+			rtm.Value.Elem().SetInt(10)
+		}
+		return nil
+	}), true)
+
+	type Request struct {
+		_          struct{}   `owl:"default=request"`
+		Pagination Pagination `owl:"form=pagination"`
+		Sort       int        `owl:"form=sort"`
+		User       *User      `owl:"form=user"`
+		Token      string     `owl:"form=token"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	req := &Request{Token: "untouched"}
+	assert.NoError(resolver.ResolveTo(req, owl.WithOnlyPaths("Pagination", "Sort")))
+	assert.Equal(&Request{Pagination: Pagination{Page: 10, Size: 10}, Sort: 10, Token: "untouched"}, req)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "pagination"),
+		owl.NewDirective("form", "page"),
+		owl.NewDirective("form", "size"),
+		owl.NewDirective("form", "sort"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// The ancestors of the selected fields are passed through.
+	tracker.Reset()
+	gotValue, err := resolver.Resolve(owl.WithOnlyPaths("User.Name"))
+	assert.NoError(err)
+	assert.NotNil(gotValue.Interface().(*Request).User)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "name"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Set in New.
+	resolver, err = owl.New(Request{}, owl.WithNamespace(ns), owl.WithOnlyPaths("Sort"))
+	assert.NoError(err)
+	tracker.Reset()
+	_, err = resolver.Resolve()
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "sort"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Malformed pattern.
+	_, err = resolver.Resolve(owl.WithOnlyPaths("Sort["))
+	assert.ErrorContains(err, `select paths "Sort[" failed`)
+}
+
+func TestResolve_WithExcludedPaths(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	_, err = resolver.Resolve(owl.WithExcludedPaths("User.G*", "CSRFToken"))
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "user"),
+		owl.NewDirective("form", "name"),
+		owl.NewDirective("form", "birthday"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	// Excluded even if selected.
+	tracker.Reset()
+	_, err = resolver.Resolve(owl.WithOnlyPaths("User"), owl.WithExcludedPaths("User.Name", "User.Birthday"))
+	assert.NoError(err)
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "user"),
+		owl.NewDirective("form", "gender"),
+		owl.NewDirective("default", "unknown"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	_, err = resolver.Resolve(owl.WithExcludedPaths("["))
+	assert.ErrorContains(err, `exclude paths "[" failed`)
+}
+
+func TestScan_WithOnlyPaths(t *testing.T) {
+	assert := assert.New(t)
+	ns, tracker := createNsForTracking()
+	resolver, err := owl.New(UserSignUpForm{}, owl.WithNamespace(ns))
+	assert.NoError(err)
+
+	assert.NoError(resolver.Scan(&UserSignUpForm{}, owl.WithOnlyPaths("**.Name", "CSRFToken")))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "name"),
+		owl.NewDirective("form", "csrf_token"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	tracker.Reset()
+	assert.NoError(resolver.Scan(&UserSignUpForm{}, owl.WithExcludedPaths("User")))
+	assert.Equal([]*owl.Directive{
+		owl.NewDirective("form", "csrf_token"),
+	}, tracker.Executed.ExecutedDirectives())
+
+	assert.ErrorContains(resolver.Scan(&UserSignUpForm{}, owl.WithOnlyPaths("[")), `select paths "[" failed`)
+}
+
+func TestResolveTo_WithTransaction(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		if rtm.Directive.Argv[0] == "fail" {
+			return errors.New("TestResolveTo_WithTransaction")
+		}
+		if rtm.Value.Elem().Kind() == reflect.String {
+			rtm.Value.Elem().SetString("new " + rtm.Directive.Argv[0])
+		}
+		return nil
+	}), true)
+
+	type Config struct {
+		Owner  *User  `owl:"form=owner"`
+		Shape  any    `owl:"form=shape"`
+		Name   string `owl:"form=name"`
+		Broken string `owl:"form=ok"`
+	}
+	resolver, err := owl.New(Config{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+	resolver.Lookup("Broken").Directives[0].Argv[0] = "fail"
+
+	owner, shape := &User{Name: "old"}, &User{Name: "old"}
+	config := &Config{Owner: owner, Shape: shape, Name: "old"}
+	assert.Error(resolver.ResolveTo(config, owl.WithTransaction(true)))
+	assert.Equal(&Config{Owner: &User{Name: "old"}, Shape: &User{Name: "old"}, Name: "old"}, config)
+	assert.Same(owner, config.Owner)
+	assert.Same(shape, config.Shape)
+
+	// Without transaction, the value is left half-populated.
+	assert.Error(resolver.ResolveTo(config))
+	assert.Equal("new name", config.Name)
+	assert.Equal("new name", owner.Name)
+
+	// Commits on success.
+	resolver.Lookup("Broken").Directives[0].Argv[0] = "broken"
+	shape = &User{Name: "old"}
+	config = &Config{Owner: owner, Shape: shape}
+	assert.NoError(resolver.ResolveTo(config, owl.WithTransaction(true)))
+	assert.Equal(&Config{
+		Owner:  &User{Name: "new name", Gender: "new gender", Birthday: "new birthday"},
+		Shape:  &User{Name: "new name", Gender: "new gender", Birthday: "new birthday"},
+		Name:   "new name",
+		Broken: "new broken",
+	}, config)
+	assert.Equal("old", shape.Name) // replaced by the copy
+}
+
+func TestResolve_WithPanicRecovery(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		rtm.Value.Elem().SetInt(10) // panics on the string fields
+		return nil
+	}), true)
+
+	type Request struct {
+		Page  int    `owl:"form=page"`
+		Token string `owl:"form=token"`
+	}
+	resolver, err := owl.New(Request{}, owl.WithNamespace(ns), owl.WithPanicRecovery(true))
+	assert.NoError(err)
+
+	_, err = resolver.Resolve()
+	assert.ErrorIs(err, owl.ErrExecutorPanic)
+	var de *owl.DirectiveExecutionError
+	assert.ErrorAs(err, &de)
+	assert.Equal("token", de.Argv[0])
+	var pe *owl.ExecutorPanicError
+	assert.ErrorAs(err, &pe)
+	assert.Contains(fmt.Sprint(pe.Value), "SetInt")
+	assert.Contains(string(pe.Stack), "executeRecovered")
+
+	err = resolver.Scan(&Request{})
+	assert.ErrorIs(err, owl.ErrExecutorPanic)
+
+	// The recovered errors are wrapped.
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		panic(io.ErrUnexpectedEOF)
+	}), true)
+	_, err = resolver.Resolve()
+	assert.ErrorIs(err, owl.ErrExecutorPanic)
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+
+	// The recovered sentinel errors are not taken as the control flow.
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		panic(owl.ErrStop)
+	}), true)
+	_, err = resolver.Resolve()
+	assert.ErrorIs(err, owl.ErrExecutorPanic)
+	assert.ErrorAs(err, &de)
+	assert.Equal("page", de.Argv[0])
+
+	// Disabled.
+	assert.Panics(func() {
+		resolver.Resolve(owl.WithPanicRecovery(false))
+	})
+}
+
+func TestResolveTo_WithTransaction_DynamicTypes(t *testing.T) {
+	assert := assert.New(t)
+	ns, _ := createNsForTracking()
+	ns.RegisterDirectiveExecutor("form", owl.DirectiveExecutorFunc(func(rtm *owl.DirectiveRuntime) error {
+		if rtm.Directive.Argv[0] == "fail" {
+			return errors.New("TestResolveTo_WithTransaction_DynamicTypes")
+		}
+		rtm.Value.Elem().SetString("new")
+		return nil
+	}), true)
+
+	type Inner struct {
+		V string `owl:"form=v"`
+	}
+	type Dyn struct {
+		In *Inner
+		F  string `owl:"form=fail"`
+	}
+	type Cfg struct {
+		Payload any
+	}
+	resolver, err := owl.New(Cfg{}, owl.WithNamespace(ns), owl.WithDynamicTypesEnabled(true))
+	assert.NoError(err)
+
+	inner := &Inner{V: "old"}
+	dyn := &Dyn{In: inner}
+	cfg := &Cfg{Payload: dyn}
+	assert.Error(resolver.ResolveTo(cfg, owl.WithTransaction(true)))
+	assert.Same(dyn, cfg.Payload)
+	assert.Same(inner, dyn.In)
+	assert.Equal("old", inner.V)
+
+	// Held by value.
+	cfg = &Cfg{Payload: Dyn{In: inner}}
+	assert.Error(resolver.ResolveTo(cfg, owl.WithTransaction(true)))
+	assert.Equal(Dyn{In: inner}, cfg.Payload)
+	assert.Equal("old", inner.V)
+
+	// Without transaction, resolved through the original pointers.
+	assert.Error(resolver.ResolveTo(cfg))
+	assert.Equal("new", inner.V)
+}
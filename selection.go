@@ -0,0 +1,88 @@
+package owl
+
+import (
+	"context"
+	"fmt"
+)
+
+// pathSelection is the fields selected for a single Resolve or Scan, see
+// WithOnlyPaths and WithExcludedPaths.
+type pathSelection struct {
+	only     map[*Resolver]bool // the fields selected by WithOnlyPaths, nil if not set
+	passed   map[*Resolver]bool // the ancestors of the fields in only
+	excluded map[*Resolver]bool // the fields selected by WithExcludedPaths
+}
+
+// selectMode tells how to visit a field with the paths selected.
+type selectMode int
+
+const (
+	selectAll  selectMode = iota // run the directives of the field
+	selectPass                   // pass through the field to reach the selected fields
+	selectNone                   // skip the field, along with its subtree
+)
+
+// selectPaths finds the fields selected by WithOnlyPaths and
+// WithExcludedPaths in the tree. Returns nil if all the fields are selected.
+func (r *Resolver) selectPaths(ctx context.Context) (*pathSelection, error) {
+	onlyPatterns, _ := lookupOption(ctx, r, ckOnlyPaths).([]string)
+	excludedPatterns, _ := lookupOption(ctx, r, ckExcludedPaths).([]string)
+	if len(onlyPatterns) == 0 && len(excludedPatterns) == 0 {
+		return nil, nil
+	}
+
+	s := &pathSelection{
+		passed:   make(map[*Resolver]bool),
+		excluded: make(map[*Resolver]bool),
+	}
+	if len(onlyPatterns) > 0 {
+		s.only = make(map[*Resolver]bool)
+	}
+	for _, pattern := range onlyPatterns {
+		found, err := r.Select(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("select paths %q failed: %w", pattern, err)
+		}
+		for _, field := range found {
+			s.only[field] = true
+			for x := field.Parent; x != nil; x = x.Parent {
+				s.passed[x] = true
+			}
+		}
+	}
+	for _, pattern := range excludedPatterns {
+		found, err := r.Select(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("exclude paths %q failed: %w", pattern, err)
+		}
+		for _, field := range found {
+			s.excluded[field] = true
+		}
+	}
+	return s, nil
+}
+
+// mode tells how to visit the field. A field is selected along with its
+// subtree, while an excluded field is skipped along with its subtree.
+func (s *pathSelection) mode(x *Resolver) selectMode {
+	if s == nil {
+		return selectAll
+	}
+
+	mode := selectAll
+	if s.only != nil {
+		mode = selectNone
+	}
+	for y := x; y != nil; y = y.Parent {
+		if s.excluded[y] {
+			return selectNone
+		}
+		if s.only[y] {
+			mode = selectAll
+		}
+	}
+	if mode == selectNone && s.passed[x] {
+		mode = selectPass
+	}
+	return mode
+}
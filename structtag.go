@@ -2,6 +2,27 @@ package owl
 
 const DefaultTagName = "owl"
 
+// ExcludeMarker is the reserved tag value to exclude a field, along with its
+// whole subtree, from the resolver tree. Ex:
+//
+//	type Request struct {
+//	    Internal InternalState `owl:"-"` // won't be resolved or scanned
+//	}
+const ExcludeMarker = "-"
+
+// BlankField is the name of the blank fields, whose tags declare the
+// struct-level directives, i.e. the directives run on the struct value itself,
+// e.g. for cross-field validations. The blank fields declared before the other
+// fields run before the children of the struct, otherwise after. Ex:
+//
+//	type SignUpForm struct {
+//	    _        struct{} `owl:"prepare"`
+//	    Password string   `owl:"form=password"`
+//	    Confirm  string   `owl:"form=confirm"`
+//	    _        struct{} `owl:"equal=Password,Confirm"`
+//	}
+const BlankField = "_"
+
 var tagName string = DefaultTagName
 
 // UseTag sets the tag name to parse directives.
@@ -0,0 +1,79 @@
+package owl
+
+// WalkAction tells Walk how to go on after visiting a node.
+type WalkAction int
+
+const (
+	WalkContinue     WalkAction = iota // go on walking
+	WalkSkipChildren                   // skip the children of the node, only works for Visitor.Enter
+	WalkStop                           // stop walking, no more nodes will be visited
+)
+
+// Visitor visits the nodes of a resolver tree, see Walk. The depth of the
+// node is relative to the node where the walk starts, whose depth is 0.
+type Visitor interface {
+	// Enter is called on entering a node, before visiting its children.
+	Enter(r *Resolver, depth int) (WalkAction, error)
+
+	// Leave is called on leaving a node, after visiting its children.
+	Leave(r *Resolver, depth int) (WalkAction, error)
+}
+
+// VisitorFuncs is an adapter to allow the use of ordinary functions as a
+// Visitor. A nil function acts as returning WalkContinue.
+type VisitorFuncs struct {
+	OnEnter func(r *Resolver, depth int) (WalkAction, error)
+	OnLeave func(r *Resolver, depth int) (WalkAction, error)
+}
+
+func (f VisitorFuncs) Enter(r *Resolver, depth int) (WalkAction, error) {
+	if f.OnEnter == nil {
+		return WalkContinue, nil
+	}
+	return f.OnEnter(r, depth)
+}
+
+func (f VisitorFuncs) Leave(r *Resolver, depth int) (WalkAction, error) {
+	if f.OnLeave == nil {
+		return WalkContinue, nil
+	}
+	return f.OnLeave(r, depth)
+}
+
+// Walk traverses the tree rooted at r in depth-first order. Unlike Iterate,
+// both entering and leaving a node are visited, which makes post-order
+// processing possible. When v.Enter returns WalkSkipChildren, the children of
+// the node are skipped, while the node is still left. When v.Enter or v.Leave
+// returns WalkStop, the walk ends immediately, without leaving the nodes being
+// visited. The walk also ends on the first error returned by v, which will be
+// returned by Walk. Resolve and Scan are both driven by Walk. Ex:
+//
+//	resolver.Walk(owl.VisitorFuncs{
+//	    OnEnter: func(r *owl.Resolver, depth int) (owl.WalkAction, error) {
+//	        fmt.Println(strings.Repeat("  ", depth) + r.String())
+//	        return owl.WalkContinue, nil
+//	    },
+//	})
+func (r *Resolver) Walk(v Visitor) error {
+	_, err := r.walk(v, 0)
+	return err
+}
+
+// walk visits the tree rooted at r, reports whether the walk was stopped.
+func (r *Resolver) walk(v Visitor, depth int) (stopped bool, err error) {
+	action, err := v.Enter(r, depth)
+	if err != nil || action == WalkStop {
+		return true, err
+	}
+
+	if action != WalkSkipChildren {
+		for _, child := range r.Children {
+			if stopped, err := child.walk(v, depth+1); stopped {
+				return true, err
+			}
+		}
+	}
+
+	action, err = v.Leave(r, depth)
+	return err != nil || action == WalkStop, err
+}
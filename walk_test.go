@@ -0,0 +1,125 @@
+package owl_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ggicci/owl"
+	"github.com/stretchr/testify/assert"
+)
+
+// walkRecorder records the visited nodes, and acts as told by the actions.
+type walkRecorder struct {
+	visited      []string
+	enterActions map[string]owl.WalkAction
+	leaveActions map[string]owl.WalkAction
+	err          error
+	errAt        string
+}
+
+func (w *walkRecorder) Enter(r *owl.Resolver, depth int) (owl.WalkAction, error) {
+	return w.visit("enter", r, depth, w.enterActions)
+}
+
+func (w *walkRecorder) Leave(r *owl.Resolver, depth int) (owl.WalkAction, error) {
+	return w.visit("leave", r, depth, w.leaveActions)
+}
+
+func (w *walkRecorder) visit(event string, r *owl.Resolver, depth int, actions map[string]owl.WalkAction) (owl.WalkAction, error) {
+	path := r.PathString()
+	w.visited = append(w.visited, fmt.Sprintf("%s %q %d", event, path, depth))
+	if w.err != nil && w.errAt == event+" "+path {
+		return owl.WalkContinue, w.err
+	}
+	return actions[path], nil
+}
+
+func TestWalk(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	w := &walkRecorder{}
+	assert.NoError(t, resolver.Walk(w))
+	assert.Equal(t, []string{
+		`enter "" 0`,
+		`enter "User" 1`,
+		`enter "User.Name" 2`,
+		`leave "User.Name" 2`,
+		`enter "User.Gender" 2`,
+		`leave "User.Gender" 2`,
+		`enter "User.Birthday" 2`,
+		`leave "User.Birthday" 2`,
+		`leave "User" 1`,
+		`enter "CSRFToken" 1`,
+		`leave "CSRFToken" 1`,
+		`leave "" 0`,
+	}, w.visited)
+
+	// Walk a subtree.
+	w = &walkRecorder{}
+	assert.NoError(t, resolver.Lookup("User").Walk(w))
+	assert.Equal(t, `enter "User" 0`, w.visited[0])
+	assert.Equal(t, `enter "User.Name" 1`, w.visited[1])
+}
+
+func TestWalk_SkipChildren(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	w := &walkRecorder{enterActions: map[string]owl.WalkAction{"User": owl.WalkSkipChildren}}
+	assert.NoError(t, resolver.Walk(w))
+	assert.Equal(t, []string{
+		`enter "" 0`,
+		`enter "User" 1`,
+		`leave "User" 1`,
+		`enter "CSRFToken" 1`,
+		`leave "CSRFToken" 1`,
+		`leave "" 0`,
+	}, w.visited)
+}
+
+func TestWalk_Stop(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	w := &walkRecorder{enterActions: map[string]owl.WalkAction{"User.Gender": owl.WalkStop}}
+	assert.NoError(t, resolver.Walk(w))
+	assert.Equal(t, []string{
+		`enter "" 0`,
+		`enter "User" 1`,
+		`enter "User.Name" 2`,
+		`leave "User.Name" 2`,
+		`enter "User.Gender" 2`,
+	}, w.visited)
+
+	w = &walkRecorder{leaveActions: map[string]owl.WalkAction{"User": owl.WalkStop}}
+	assert.NoError(t, resolver.Walk(w))
+	assert.Equal(t, `leave "User" 1`, w.visited[len(w.visited)-1])
+}
+
+func TestWalk_Error(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	errBoom := errors.New("boom")
+	w := &walkRecorder{err: errBoom, errAt: "leave User.Name"}
+	assert.ErrorIs(t, resolver.Walk(w), errBoom)
+	assert.Equal(t, `leave "User.Name" 2`, w.visited[len(w.visited)-1])
+}
+
+func TestVisitorFuncs(t *testing.T) {
+	resolver, err := owl.New(UserSignUpForm{})
+	assert.NoError(t, err)
+
+	var leaves []string
+	assert.NoError(t, resolver.Walk(owl.VisitorFuncs{
+		OnLeave: func(r *owl.Resolver, depth int) (owl.WalkAction, error) {
+			if r.IsLeaf() {
+				leaves = append(leaves, r.PathString())
+			}
+			return owl.WalkContinue, nil
+		},
+	}))
+	assert.Equal(t, []string{"User.Name", "User.Gender", "User.Birthday", "CSRFToken"}, leaves)
+}